@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// agentChainDiagram draws the enabled agents as the pipeline sendChatMessage
+// runs: one box per agent top to bottom, with consecutive parallel agents
+// side by side since they all get the same input. On narrow terminals the
+// parallel agents are stacked too and boxes wrap to fit width.
+func agentChainDiagram(agents []Agent, width int) string {
+	compact := isCompact(width)
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Accent).
+		Padding(0, 1)
+	if compact {
+		// Width includes the padding but not the border
+		boxStyle = boxStyle.Width(width - 2)
+	}
+	mutedStyle := lipgloss.NewStyle().Foreground(activeTheme.Muted)
+	arrow := mutedStyle.Render("  │\n  ▼")
+
+	var enabled []Agent
+	var disabled []string
+	for _, agent := range agents {
+		if agent.Enabled {
+			enabled = append(enabled, agent)
+		} else {
+			disabled = append(disabled, agent.Role)
+		}
+	}
+
+	if len(enabled) == 0 {
+		return mutedStyle.Render("No enabled agents. Enable one in the agent view to build a chain.")
+	}
+
+	stages := []string{boxStyle.BorderForeground(activeTheme.Muted).Render("Your message")}
+	for i := 0; i < len(enabled); {
+		end := i + 1
+		if enabled[i].Parallel {
+			for end < len(enabled) && enabled[end].Parallel {
+				end++
+			}
+		}
+
+		var boxes []string
+		for j := i; j < end; j++ {
+			boxes = append(boxes, boxStyle.Render(agentChainBox(j+1, enabled[j])))
+		}
+		stage := lipgloss.JoinHorizontal(lipgloss.Top, boxes...)
+		if compact {
+			stage = lipgloss.JoinVertical(lipgloss.Left, boxes...)
+		}
+		if end-i > 1 {
+			stage = mutedStyle.Render("in parallel, each gets your original message; replies are combined") + "\n" + stage
+		}
+		stages = append(stages, stage)
+		i = end
+	}
+	stages = append(stages, boxStyle.BorderForeground(activeTheme.Muted).Render("Response"))
+
+	diagram := strings.Join(stages, "\n"+arrow+"\n")
+	if len(disabled) > 0 {
+		diagram += "\n\n" + mutedStyle.Render("Skipped (disabled): "+strings.Join(disabled, ", "))
+	}
+	return diagram
+}
+
+func agentChainBox(position int, agent Agent) string {
+	model := agent.ModelVersion
+	if model == "" {
+		model = "no model"
+	}
+	if fallback := strings.TrimSpace(agent.FallbackModel); fallback != "" {
+		model += " (fallback " + fallback + ")"
+	}
+
+	context := "no context"
+	if agent.UseContext && agent.ContextFilePath != "" {
+		context = "context: " + agent.ContextFilePath
+	}
+	conversation := "this message only"
+	if agent.UseConversation {
+		conversation = "full conversation"
+	}
+	if position > 1 && !agent.Parallel && !agent.UseChainInput {
+		conversation += ", gets your original message"
+	}
+
+	lines := []string{
+		lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("%d. %s", position, agent.Role)),
+		model,
+		context,
+		conversation,
+	}
+	if agent.ForceJSON {
+		if strings.TrimSpace(agent.JSONSchema) != "" {
+			lines = append(lines, "JSON output (schema)")
+		} else {
+			lines = append(lines, "JSON output")
+		}
+	}
+	if timeout := agent.requestTimeout(); timeout > 0 {
+		lines = append(lines, fmt.Sprintf("timeout %s", timeout))
+	}
+	if len(agent.Tools) > 0 {
+		names := make([]string, len(agent.Tools))
+		for i, tool := range agent.Tools {
+			names[i] = tool.Name
+		}
+		lines = append(lines, "tools: "+strings.Join(names, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showAgentChain opens the read-only pipeline view for the current agents.
+func (m *model) showAgentChain() {
+	m.previewViewport.Width = m.width
+	m.previewViewport.Height = m.height - 2
+	m.previewViewport.SetContent(agentChainDiagram(m.agents, m.width))
+	m.previewViewport.GotoTop()
+	m.viewMode = AgentChainView
+	m.agentsTable.Blur()
+}
+
+func (m model) agentChainView() string {
+	hint := lipgloss.NewStyle().Foreground(activeTheme.Muted).
+		Render("Agent chain — each agent's reply is the next one's input. j/k to scroll, esc to go back.")
+	return hint + "\n\n" + m.previewViewport.View()
+}
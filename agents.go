@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,20 +23,21 @@ func saveAgents(m *model) error {
 		return fmt.Errorf("failed to marshal agents: %w", err)
 	}
 
-	err = os.WriteFile(agentsFilePath, data, 0644)
+	err = writeFileAtomic(m.agentsFilePath, data, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write agents to file: %w", err)
 	}
+	m.savedAgents = data
 
 	return nil
 }
 
 func loadAgents(m *model) error {
-	if _, err := os.Stat(agentsFilePath); os.IsNotExist(err) {
+	if _, err := os.Stat(m.agentsFilePath); os.IsNotExist(err) {
 		return nil
 	}
 
-	data, err := os.ReadFile(agentsFilePath)
+	data, err := os.ReadFile(m.agentsFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to read agents file: %w", err)
 	}
@@ -37,60 +45,561 @@ func loadAgents(m *model) error {
 	var loadedAgents []Agent
 	err = json.Unmarshal(data, &loadedAgents)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal agents: %w", err)
+		return &corruptFileError{path: m.agentsFilePath, err: err}
 	}
 
+	normalizeAgentTokens(loadedAgents, m.agentsFilePath)
 	m.agents = loadedAgents
+	m.savedAgents, _ = json.MarshalIndent(loadedAgents, "", "  ")
 
 	return nil
 }
 
-func (m *model) moveAgentUp() {
-	cursor := m.agentsTable.Cursor()
-	log.Printf("Attempting to move agent up. Cursor: %d, Agents Length: %d", cursor, len(m.agents))
+// hasUnsavedAgentChanges reports whether the agents in memory differ from
+// what was last loaded or saved, e.g. a reorder whose save failed.
+func (m *model) hasUnsavedAgentChanges() bool {
+	data, err := json.MarshalIndent(m.agents, "", "  ")
+	if err != nil {
+		return false
+	}
+	return !bytes.Equal(data, m.savedAgents)
+}
 
-	if cursor <= 0 || cursor > len(m.agents) {
-		log.Println("Invalid cursor position for moving up.")
-		return
+// reloadAgents re-reads the agents file, picking up changes made outside the app.
+func (m *model) reloadAgents() tea.Cmd {
+	if err := loadAgents(m); err != nil {
+		return func() tea.Msg { return errMsg(err) }
+	}
+	m.populateAgentsTable()
+	count, path := len(m.agents), m.agentsFilePath
+	return func() tea.Msg {
+		return notifyMsg(fmt.Sprintf("Reloaded %d agent(s) from %s.", count, path))
 	}
+}
 
-	agentIndex := cursor - 1
-	log.Printf("Agent Index for moving up: %d", agentIndex)
+// UnmarshalJSON defaults Enabled and UseChainInput to true so agents saved
+// before the fields existed keep their place and input in the chain.
+func (a *Agent) UnmarshalJSON(data []byte) error {
+	type agentAlias Agent
+	alias := agentAlias{Enabled: true, UseChainInput: true}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*a = Agent(alias)
+	return nil
+}
 
-	if agentIndex > 0 {
-		m.agents[agentIndex], m.agents[agentIndex-1] = m.agents[agentIndex-1], m.agents[agentIndex]
+// parsePositiveInt reads a whole number such as a token limit or timeout,
+// accepting only positive integers.
+func parsePositiveInt(s string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
 
-		m.populateAgentsTable()
+// contextWindow returns the agent's token limit, or defaultContextWindow if
+// Tokens somehow isn't a positive integer.
+func (a Agent) contextWindow() int {
+	if n, ok := parsePositiveInt(a.Tokens); ok {
+		return n
+	}
+	return defaultContextWindow
+}
 
-		m.agentsTable.SetCursor(cursor - 1)
-		log.Printf("Moved agent up. New cursor position: %d", cursor-1)
+// normalizeAgentTokens replaces token limits that aren't positive integers,
+// e.g. from a hand-edited or imported file, with defaultTokens.
+func normalizeAgentTokens(agents []Agent, source string) {
+	for i := range agents {
+		if n, ok := parsePositiveInt(agents[i].Tokens); ok {
+			agents[i].Tokens = strconv.Itoa(n)
+			continue
+		}
+		log.Printf("Agent '%s' in %s has invalid token limit %q, using %s", agents[i].Role, source, agents[i].Tokens, defaultTokens)
+		agents[i].Tokens = defaultTokens
+	}
+}
 
-		return
+func (m *model) enabledAgents() []Agent {
+	agents := make([]Agent, 0, len(m.agents))
+	for _, agent := range m.agents {
+		if agent.Enabled {
+			if model, ok := m.modelOverrides[agent.Role]; ok {
+				agent.ModelVersion = model
+			}
+			agents = append(agents, agent)
+		}
 	}
+	return agents
 }
 
-func (m *model) moveAgentDown() {
-	cursor := m.agentsTable.Cursor()
-	log.Printf("Attempting to move agent down. Cursor: %d, Agents Length: %d", cursor, len(m.agents))
+// confirmNoAgents offers to open the Agent view when no agent is enabled, so
+// the user finds out before writing a message that nothing would answer. It
+// reports whether the prompt was shown.
+func (m *model) confirmNoAgents() bool {
+	if len(m.enabledAgents()) > 0 {
+		return false
+	}
 
-	if cursor <= 0 || cursor > len(m.agents) {
-		log.Println("Invalid cursor position for moving down.")
-		return
+	title := "No agents are configured, so messages can't be answered. Open the Agent view to add one?"
+	if len(m.agents) > 0 {
+		title = "All agents are disabled, so messages can't be answered. Open the Agent view to enable one?"
 	}
+	m.confirmDeleteType = "agents"
+	m.confirmForm = createConfirmForm(title, &m.confirmResult)
+	m.viewMode = ConfirmDelete
+	return true
+}
 
-	agentIndex := cursor - 1
-	log.Printf("Agent Index for moving down: %d", agentIndex)
+// openModelSwitch shows the compact model picker for the only enabled agent.
+func (m *model) openModelSwitch() tea.Cmd {
+	agents := m.enabledAgents()
+	if len(agents) != 1 {
+		return m.showToast("Quick model switching needs exactly one enabled agent; change models in the Agent view.")
+	}
+
+	var versions []string
+	for _, version := range m.availableModelVersions {
+		if version != "" {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return m.showToast("No installed models found; press m to install one.")
+	}
+
+	m.modelSwitchRole = agents[0].Role
+	m.modelSwitchVersion = agents[0].ModelVersion
+	m.modelSwitchSave = false
+	m.modelSwitchForm = createModelSwitchForm(m.modelSwitchRole, versions, &m.modelSwitchVersion, &m.modelSwitchSave)
+	m.viewMode = ModelSwitchFormView
+	m.formActive = true
+	m.textarea.Blur()
+	return nil
+}
 
-	if agentIndex < len(m.agents)-1 {
-		m.agents[agentIndex], m.agents[agentIndex+1] = m.agents[agentIndex+1], m.agents[agentIndex]
+// applyModelSwitch uses the picked model for later messages, saving it to
+// the agent if asked to and otherwise keeping it until agentui exits.
+func (m *model) applyModelSwitch() tea.Cmd {
+	role, version := m.modelSwitchRole, m.modelSwitchVersion
+	index := -1
+	for i, agent := range m.agents {
+		if strings.EqualFold(agent.Role, role) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return m.showToast(fmt.Sprintf("Agent '%s' no longer exists.", role))
+	}
 
+	if !m.modelSwitchSave {
+		if version == m.agents[index].ModelVersion {
+			delete(m.modelOverrides, role)
+		} else {
+			if m.modelOverrides == nil {
+				m.modelOverrides = make(map[string]string)
+			}
+			m.modelOverrides[role] = version
+		}
+		return m.showToast(fmt.Sprintf("%s uses %s for this session.", role, version))
+	}
+
+	m.agents[index].ModelVersion = version
+	delete(m.modelOverrides, role)
+	m.populateAgentsTable()
+	if err := saveAgents(m); err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to save agents: %w", err)) }
+	}
+	return m.showToast(fmt.Sprintf("%s now uses %s.", role, version))
+}
+
+// selectedTools resolves tool names picked in a form to the available tools,
+// skipping any that are no longer registered.
+func (m *model) selectedTools(names []string) []Tool {
+	tools := []Tool{}
+	for _, name := range names {
+		for _, availableTool := range m.availableTools {
+			if availableTool.Name == name {
+				tools = append(tools, availableTool)
+				break
+			}
+		}
+	}
+	return tools
+}
+
+// openAgentTools opens the tool checklist for the agent under the cursor.
+func (m *model) openAgentTools() tea.Cmd {
+	agent, ok := m.hoveredAgent()
+	if !ok {
+		return nil
+	}
+	if len(m.availableTools) == 0 {
+		return m.showToast("No tools are available to assign.")
+	}
+
+	m.agentToolsRole = agent.Role
+	m.agentToolsSelection = append([]string{}, agent.SelectedTools...)
+	m.agentToolsForm = createAgentToolsForm(agent.Role, m.availableTools, &m.agentToolsSelection)
+	m.viewMode = AgentToolsFormView
+	m.formActive = true
+	m.agentsTable.Blur()
+	return nil
+}
+
+// applyAgentTools saves the tools picked in the checklist to their agent,
+// updating Tools the same way the agent form does.
+func (m *model) applyAgentTools() tea.Cmd {
+	for i := range m.agents {
+		if !strings.EqualFold(m.agents[i].Role, m.agentToolsRole) {
+			continue
+		}
+		m.agents[i].SelectedTools = m.agentToolsSelection
+		m.agents[i].Tools = m.selectedTools(m.agentToolsSelection)
+
+		cursor := m.agentsTable.Cursor()
 		m.populateAgentsTable()
+		m.agentsTable.SetCursor(cursor)
+		if err := saveAgents(m); err != nil {
+			return func() tea.Msg { return errMsg(fmt.Errorf("failed to save agents: %w", err)) }
+		}
+		return m.showToast(fmt.Sprintf("%s now has %d tool(s).", m.agents[i].Role, len(m.agents[i].Tools)))
+	}
+	return m.showToast(fmt.Sprintf("Agent '%s' no longer exists.", m.agentToolsRole))
+}
+
+// toggleHoveredAgent enables or disables the agent under the cursor.
+func (m *model) toggleHoveredAgent() bool {
+	agent, ok := m.hoveredAgent()
+	if !ok {
+		return false
+	}
+
+	cursor := m.agentsTable.Cursor()
+	for i := range m.agents {
+		if strings.EqualFold(m.agents[i].Role, agent.Role) {
+			m.agents[i].Enabled = !m.agents[i].Enabled
+			break
+		}
+	}
+	m.populateAgentsTable()
+	m.agentsTable.SetCursor(cursor)
+	return true
+}
+
+// outputFormat is the Ollama "format" value for the agent: its JSON schema,
+// plain "json" when it forces JSON without one, or nil for free text.
+func (a Agent) outputFormat() interface{} {
+	if !a.ForceJSON {
+		return nil
+	}
+	if schema := strings.TrimSpace(a.JSONSchema); schema != "" {
+		return json.RawMessage(schema)
+	}
+	return "json"
+}
+
+// requestTimeout is how long a single request to the agent's model may take,
+// or zero to fall back to httpClient's default.
+func (a Agent) requestTimeout() time.Duration {
+	if n, ok := parsePositiveInt(a.TimeoutSeconds); ok {
+		return time.Duration(n) * time.Second
+	}
+	return 0
+}
+
+// requestContext returns the context for one request to the agent's model,
+// with a deadline when the agent has its own timeout.
+func (a Agent) requestContext() (context.Context, context.CancelFunc) {
+	if timeout := a.requestTimeout(); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// timeoutError rewrites err to name the agent and how long it waited when ctx
+// ran past its deadline; other errors are returned unchanged.
+func (a Agent) timeoutError(ctx context.Context, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("agent %s timed out after waiting %s for %s: %w", a.Role, a.requestTimeout(), a.ModelVersion, err)
+}
+
+// applySamplingOptions adds the agent's sampling overrides and stop sequences
+// to an Ollama options map. Unset or unparsable values are left out so Ollama falls back
+// to the model defaults.
+func (a Agent) applySamplingOptions(options map[string]interface{}) {
+	floats := map[string]string{
+		"temperature":    a.Temperature,
+		"top_p":          a.TopP,
+		"repeat_penalty": a.RepeatPenalty,
+	}
+	for key, value := range floats {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			options[key] = f
+		}
+	}
+
+	if k, err := strconv.Atoi(strings.TrimSpace(a.TopK)); err == nil {
+		options["top_k"] = k
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(a.MaxTokens)); err == nil {
+		options["num_predict"] = n
+	}
+
+	if len(a.StopSequences) > 0 {
+		options["stop"] = a.StopSequences
+	}
+}
+
+// parseStopSequences splits comma-separated form input, dropping blank
+// entries.
+func parseStopSequences(input string) []string {
+	var stops []string
+	for _, stop := range strings.Split(input, ",") {
+		if stop = strings.TrimSpace(stop); stop != "" {
+			stops = append(stops, stop)
+		}
+	}
+	return stops
+}
 
-		m.agentsTable.SetCursor(cursor + 1)
-		log.Printf("Moved agent down. New cursor position: %d", cursor+1)
+// agentRolesExcept returns the roles of all agents other than the one with
+// the given role, compared case-insensitively like other agent lookups.
+func (m *model) agentRolesExcept(role string) []string {
+	roles := make([]string, 0, len(m.agents))
+	for _, agent := range m.agents {
+		if role != "" && strings.EqualFold(agent.Role, role) {
+			continue
+		}
+		roles = append(roles, agent.Role)
+	}
+	return roles
+}
+
+func exportAgents(agents []Agent, path string) error {
+	data, err := json.MarshalIndent(agents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal agents: %w", err)
+	}
+
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write agents to file: %w", err)
+	}
 
-		return
+	return nil
+}
+
+func readAgentsFile(path string) ([]Agent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agents file: %w", err)
+	}
+
+	var agents []Agent
+	if err := json.Unmarshal(data, &agents); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal agents: %w", err)
 	}
+	normalizeAgentTokens(agents, path)
+
+	for i, agent := range agents {
+		if strings.TrimSpace(agent.Role) == "" {
+			return nil, fmt.Errorf("agent %d is missing a role", i+1)
+		}
+		if strings.TrimSpace(agent.ModelVersion) == "" {
+			return nil, fmt.Errorf("agent '%s' is missing a model version", agent.Role)
+		}
+	}
+
+	return agents, nil
+}
+
+// mergeAgents adds imported agents to existing ones. When a role already
+// exists the strategy decides whether to "skip" the import, "overwrite" the
+// existing agent, or "rename" the imported one.
+func mergeAgents(existing []Agent, imported []Agent, strategy string) (merged []Agent, added int, skipped int) {
+	merged = append([]Agent{}, existing...)
+
+	indexOf := func(role string) int {
+		for i, agent := range merged {
+			if strings.EqualFold(agent.Role, role) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, agent := range imported {
+		i := indexOf(agent.Role)
+		if i < 0 {
+			merged = append(merged, agent)
+			added++
+			continue
+		}
+
+		switch strategy {
+		case "overwrite":
+			merged[i] = agent
+			added++
+		case "rename":
+			base := agent.Role
+			for n := 2; indexOf(agent.Role) >= 0; n++ {
+				agent.Role = fmt.Sprintf("%s (%d)", base, n)
+			}
+			merged = append(merged, agent)
+			added++
+		default:
+			skipped++
+		}
+	}
+
+	return merged, added, skipped
+}
+
+func exportAgentsCmd(agents []Agent, path string) tea.Cmd {
+	return func() tea.Msg {
+		if err := exportAgents(agents, path); err != nil {
+			return errMsg(err)
+		}
+		return notifyMsg(fmt.Sprintf("Exported %d agent(s) to %s", len(agents), path))
+	}
+}
+
+func (m *model) importAgents(path string, strategy string) (string, error) {
+	imported, err := readAgentsFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	merged, added, skipped := mergeAgents(m.agents, imported, strategy)
+	m.agents = merged
+	m.populateAgentsTable()
+
+	if err := saveAgents(m); err != nil {
+		return "", fmt.Errorf("failed to save agents: %w", err)
+	}
+
+	return fmt.Sprintf("Imported %d agent(s), skipped %d.", added, skipped), nil
+}
+
+// cloneAgent copies an agent with a unique " (copy)" role. Slices are copied
+// so editing the clone can't mutate the original.
+func cloneAgent(agent Agent, takenRoles []string) Agent {
+	clone := agent
+	clone.SelectedTools = append([]string{}, agent.SelectedTools...)
+	clone.Tools = append([]Tool{}, agent.Tools...)
+	clone.StopSequences = append([]string(nil), agent.StopSequences...)
+
+	isTaken := func(role string) bool {
+		for _, taken := range takenRoles {
+			if strings.EqualFold(taken, role) {
+				return true
+			}
+		}
+		return false
+	}
+
+	clone.Role = agent.Role + " (copy)"
+	for n := 2; isTaken(clone.Role); n++ {
+		clone.Role = fmt.Sprintf("%s (copy %d)", agent.Role, n)
+	}
+
+	return clone
+}
+
+// hoveredAgent returns the agent under the table cursor, skipping the
+// "Add New Agent" row.
+func (m *model) hoveredAgent() (Agent, bool) {
+	selectedRow := m.agentsTable.SelectedRow()
+	if selectedRow == nil || selectedRow[0] == "Add New Agent" {
+		return Agent{}, false
+	}
+	for _, agent := range m.agents {
+		if strings.EqualFold(agent.Role, selectedRow[0]) {
+			return agent, true
+		}
+	}
+	return Agent{}, false
+}
+
+// moveAgent swaps the agent on the given table row with the one delta rows
+// away. Row 0 is the "Add New Agent" sentinel, so agent i is on row i+1. It
+// returns the row the agent moved to, or the unchanged cursor if the move
+// would leave the agent list.
+func moveAgent(agents []Agent, cursor int, delta int) (int, bool) {
+	index := cursor - 1
+	target := index + delta
+	if index < 0 || index >= len(agents) || target < 0 || target >= len(agents) {
+		return cursor, false
+	}
+
+	agents[index], agents[target] = agents[target], agents[index]
+	return target + 1, true
+}
+
+func (m *model) moveAgentUp() bool {
+	return m.moveHoveredAgent(-1)
+}
+
+func (m *model) moveAgentDown() bool {
+	return m.moveHoveredAgent(1)
+}
+
+func (m *model) moveHoveredAgent(delta int) bool {
+	cursor := m.agentsTable.Cursor()
+	before := agentOrder{roles: make([]string, len(m.agents)), cursor: cursor}
+	for i, agent := range m.agents {
+		before.roles[i] = agent.Role
+	}
+
+	newCursor, moved := moveAgent(m.agents, cursor, delta)
+	if !moved {
+		log.Printf("Cannot move agent at cursor %d by %d (agents: %d)", cursor, delta, len(m.agents))
+		return false
+	}
+
+	m.agentOrderUndo = append(m.agentOrderUndo, before)
+	if len(m.agentOrderUndo) > maxAgentOrderUndo {
+		m.agentOrderUndo = m.agentOrderUndo[len(m.agentOrderUndo)-maxAgentOrderUndo:]
+	}
+
+	m.populateAgentsTable()
+	m.agentsTable.SetCursor(newCursor)
+	return true
+}
+
+// undoAgentMove restores the order from before the last move. Agents are
+// matched by role, so edits made since the move are kept; agents added since
+// then stay at the end of the chain.
+func (m *model) undoAgentMove() bool {
+	if len(m.agentOrderUndo) == 0 {
+		return false
+	}
+	last := m.agentOrderUndo[len(m.agentOrderUndo)-1]
+	m.agentOrderUndo = m.agentOrderUndo[:len(m.agentOrderUndo)-1]
+
+	position := make(map[string]int, len(last.roles))
+	for i, role := range last.roles {
+		position[strings.ToLower(role)] = i
+	}
+	rank := func(agent Agent) int {
+		if i, ok := position[strings.ToLower(agent.Role)]; ok {
+			return i
+		}
+		return len(last.roles)
+	}
+	sort.SliceStable(m.agents, func(i, j int) bool {
+		return rank(m.agents[i]) < rank(m.agents[j])
+	})
+
+	m.populateAgentsTable()
+	m.agentsTable.SetCursor(min(last.cursor, len(m.agents)))
+	return true
 }
 
 func (m *model) populateAgentsTable() {
@@ -99,9 +608,14 @@ func (m *model) populateAgentsTable() {
 	rows = append(rows, table.Row{"Add New Agent"})
 
 	for _, agent := range m.agents {
+		enabled := "yes"
+		if !agent.Enabled {
+			enabled = "no"
+		}
 		rows = append(rows, table.Row{
 			agent.Role,
 			agent.ModelVersion,
+			enabled,
 		})
 	}
 
@@ -112,19 +626,49 @@ func (m *model) populateAgentsTable() {
 	}
 }
 
+// saveAgentsCmd writes the agents in the background. They are marshalled now,
+// on the main loop, and the written data comes back in an agentsSavedMsg so
+// only Update touches savedAgents.
 func saveAgentsCmd(m *model) tea.Cmd {
+	data, err := json.MarshalIndent(m.agents, "", "  ")
+	if err != nil {
+		return func() tea.Msg {
+			return errMsg(fmt.Errorf("failed to save agents: failed to marshal agents: %w", err))
+		}
+	}
+	path := m.agentsFilePath
 	return func() tea.Msg {
-		err := saveAgents(m)
-		if err != nil {
-			return errMsg(fmt.Errorf("failed to save agents: %w", err))
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return errMsg(fmt.Errorf("failed to save agents: failed to write agents to file: %w", err))
 		}
-		return notifyMsg("Agents reordered and saved successfully.")
+		return agentsSavedMsg{data: data}
 	}
 }
 
 func (m model) agentView() string {
+	// the model list is refreshed on entering this view for the agent form
+	loading := ""
+	if m.modelsLoading {
+		loading = fmt.Sprintf("  %s Loading models...", m.spinner.View())
+	}
+	// the table's height assumes one line above and below it, so keep the
+	// instructions from wrapping on narrow terminals
+	if isCompact(m.width) {
+		if len(m.agents) == 0 {
+			return fmt.Sprintf("No agents yet:%s\n\n%s\n\nenter add · i import · g back", loading, m.agentsTable.View())
+		}
+		return fmt.Sprintf("Agents:%s\n\n%s\n\na add · e edit · d delete · ? more", loading, m.agentsTable.View())
+	}
+	if len(m.agents) == 0 {
+		return fmt.Sprintf(
+			"No agents yet. Press enter on 'Add New Agent' to create your first one and pick its model:%s\n\n%s\n\nPress 'i' to Import agents, 'g' to Go Back.",
+			loading,
+			m.agentsTable.View(),
+		)
+	}
 	return fmt.Sprintf(
-		"Agents (Press 'u' to move up, 'y' to move down):\n\n%s\n\nPress 'a' to Add, 'e' to Edit, 'd' to Delete an agent, 'g' to Go Back.",
+		"Agents (Press 'u' to move up, 'y' to move down, space to enable/disable):%s\n\n%s\n\nPress 'a' to Add, 'e' to Edit, 'd' to Delete, 'c' to Clone an agent, 't' to pick its Tools, 'x' to Export, 'i' to Import, 'v' to View the chain, 'r' to Reload from disk, ctrl+u to Undo a move, 'g' to Go Back.",
+		loading,
 		m.agentsTable.View(),
 	)
 }
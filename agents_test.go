@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func agentsWithRoles(roles ...string) []Agent {
+	agents := make([]Agent, len(roles))
+	for i, role := range roles {
+		agents[i] = Agent{Role: role}
+	}
+	return agents
+}
+
+func agentRoles(agents []Agent) []string {
+	roles := make([]string, len(agents))
+	for i, agent := range agents {
+		roles[i] = agent.Role
+	}
+	return roles
+}
+
+func newAgentTestModel(roles ...string) *model {
+	m := &model{
+		agents: agentsWithRoles(roles...),
+		agentsTable: table.New(table.WithColumns([]table.Column{
+			{Title: "Role", Width: 20},
+			{Title: "Model Version", Width: 40},
+			{Title: "Enabled", Width: 8},
+		})),
+	}
+	m.populateAgentsTable()
+	return m
+}
+
+func TestMoveAgent(t *testing.T) {
+	many := make([]string, 6)
+	for i := range many {
+		many[i] = fmt.Sprintf("agent%d", i)
+	}
+
+	tests := []struct {
+		name       string
+		roles      []string
+		cursor     int
+		delta      int
+		wantRoles  []string
+		wantCursor int
+		wantMoved  bool
+	}{
+		{
+			name:       "no agents, sentinel row",
+			roles:      nil,
+			cursor:     0,
+			delta:      1,
+			wantRoles:  []string{},
+			wantCursor: 0,
+		},
+		{
+			name:       "one agent up",
+			roles:      []string{"a"},
+			cursor:     1,
+			delta:      -1,
+			wantRoles:  []string{"a"},
+			wantCursor: 1,
+		},
+		{
+			name:       "one agent down",
+			roles:      []string{"a"},
+			cursor:     1,
+			delta:      1,
+			wantRoles:  []string{"a"},
+			wantCursor: 1,
+		},
+		{
+			name:       "sentinel row selected",
+			roles:      []string{"a", "b"},
+			cursor:     0,
+			delta:      1,
+			wantRoles:  []string{"a", "b"},
+			wantCursor: 0,
+		},
+		{
+			name:       "two agents, first moves down",
+			roles:      []string{"a", "b"},
+			cursor:     1,
+			delta:      1,
+			wantRoles:  []string{"b", "a"},
+			wantCursor: 2,
+			wantMoved:  true,
+		},
+		{
+			name:       "two agents, last moves up",
+			roles:      []string{"a", "b"},
+			cursor:     2,
+			delta:      -1,
+			wantRoles:  []string{"b", "a"},
+			wantCursor: 1,
+			wantMoved:  true,
+		},
+		{
+			name:       "two agents, first can't move up",
+			roles:      []string{"a", "b"},
+			cursor:     1,
+			delta:      -1,
+			wantRoles:  []string{"a", "b"},
+			wantCursor: 1,
+		},
+		{
+			name:       "two agents, last can't move down",
+			roles:      []string{"a", "b"},
+			cursor:     2,
+			delta:      1,
+			wantRoles:  []string{"a", "b"},
+			wantCursor: 2,
+		},
+		{
+			name:       "many agents, middle moves up",
+			roles:      many,
+			cursor:     4,
+			delta:      -1,
+			wantRoles:  []string{"agent0", "agent1", "agent3", "agent2", "agent4", "agent5"},
+			wantCursor: 3,
+			wantMoved:  true,
+		},
+		{
+			name:       "many agents, second to last moves down",
+			roles:      many,
+			cursor:     5,
+			delta:      1,
+			wantRoles:  []string{"agent0", "agent1", "agent2", "agent3", "agent5", "agent4"},
+			wantCursor: 6,
+			wantMoved:  true,
+		},
+		{
+			name:       "cursor past the end",
+			roles:      many,
+			cursor:     7,
+			delta:      -1,
+			wantRoles:  many,
+			wantCursor: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agents := agentsWithRoles(tt.roles...)
+
+			cursor, moved := moveAgent(agents, tt.cursor, tt.delta)
+
+			if moved != tt.wantMoved {
+				t.Errorf("moved = %v, want %v", moved, tt.wantMoved)
+			}
+			if cursor != tt.wantCursor {
+				t.Errorf("cursor = %d, want %d", cursor, tt.wantCursor)
+			}
+			if got := agentRoles(agents); !reflect.DeepEqual(got, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", got, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestMoveAgentUpDown(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		cursor     int
+		up         bool
+		wantRoles  []string
+		wantCursor int
+	}{
+		{
+			name:       "no agents",
+			roles:      nil,
+			cursor:     0,
+			up:         false,
+			wantRoles:  []string{},
+			wantCursor: 0,
+		},
+		{
+			name:       "single agent stays put",
+			roles:      []string{"a"},
+			cursor:     1,
+			up:         true,
+			wantRoles:  []string{"a"},
+			wantCursor: 1,
+		},
+		{
+			name:       "first agent moves down",
+			roles:      []string{"a", "b", "c"},
+			cursor:     1,
+			up:         false,
+			wantRoles:  []string{"b", "a", "c"},
+			wantCursor: 2,
+		},
+		{
+			name:       "last agent moves up",
+			roles:      []string{"a", "b", "c"},
+			cursor:     3,
+			up:         true,
+			wantRoles:  []string{"a", "c", "b"},
+			wantCursor: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newAgentTestModel(tt.roles...)
+			m.agentsTable.SetCursor(tt.cursor)
+
+			if tt.up {
+				m.moveAgentUp()
+			} else {
+				m.moveAgentDown()
+			}
+
+			if got := agentRoles(m.agents); !reflect.DeepEqual(got, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", got, tt.wantRoles)
+			}
+			if got := m.agentsTable.Cursor(); got != tt.wantCursor {
+				t.Errorf("cursor = %d, want %d", got, tt.wantCursor)
+			}
+			if rows := len(m.agentsTable.Rows()); m.agentsTable.Cursor() >= rows {
+				t.Errorf("cursor %d out of range for %d rows", m.agentsTable.Cursor(), rows)
+			}
+		})
+	}
+}
+
+func TestReloadAgentsKey(t *testing.T) {
+	m := newAgentTestModel("Writer")
+	m.viewMode = AgentView
+	m.agentsFilePath = filepath.Join(t.TempDir(), agentsFileName)
+	// the in-memory agents match what was last saved, so no confirm is needed
+	m.savedAgents, _ = json.MarshalIndent(m.agents, "", "  ")
+
+	onDisk := `[{"role": "Writer"}, {"role": "Reviewer"}]`
+	if err := os.WriteFile(m.agentsFilePath, []byte(onDisk), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+
+	if m.viewMode != AgentView {
+		t.Errorf("viewMode = %v, want AgentView", m.viewMode)
+	}
+	if got, want := agentRoles(m.agents), []string{"Writer", "Reviewer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("agents after 'r' = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+)
+
+// apiLog records the raw chat requests sent to Ollama and the responses that
+// come back. It is nil unless --api-log or AGENTUI_API_LOG names a file, and
+// the logging helpers do nothing while it is.
+var apiLog *log.Logger
+
+// openAPILog appends API traffic to the file at path from now on.
+func openAPILog(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open API log: %w", err)
+	}
+	apiLog = log.New(f, "", log.LstdFlags|log.Lmicroseconds)
+	return f, nil
+}
+
+func logAPIRequest(url string, body []byte) {
+	if apiLog == nil {
+		return
+	}
+	apiLog.Printf("request POST %s\n%s\n", url, redactImages(body))
+}
+
+// logAPIResponse writes resp's body to the API log and swaps in a copy of it,
+// so the caller can still read the body afterwards.
+func logAPIResponse(url string, resp *http.Response) {
+	if apiLog == nil {
+		return
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		apiLog.Printf("response %s from %s (read failed: %v)\n%s\n", resp.Status, url, err, data)
+		return
+	}
+	apiLog.Printf("response %s from %s\n%s\n", resp.Status, url, redactImages(data))
+}
+
+// redactImages replaces base64 image data in a JSON body with its size. The
+// body is returned untouched when it has no images or isn't JSON.
+func redactImages(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil || !redactImageData(value) {
+		return string(body)
+	}
+	redacted, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactImageData(value interface{}) bool {
+	redacted := false
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if images, ok := field.([]interface{}); ok && key == "images" {
+				for i, image := range images {
+					if data, ok := image.(string); ok {
+						images[i] = fmt.Sprintf("[image: %d bytes of base64]", len(data))
+						redacted = true
+					}
+				}
+				continue
+			}
+			if redactImageData(field) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if redactImageData(item) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
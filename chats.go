@@ -4,32 +4,40 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/google/uuid"
 )
 
-func newChatDelegate() chatDelegate {
-	d := chatDelegate{}
+func newChatDelegate(marked map[string]bool) chatDelegate {
+	d := chatDelegate{marked: marked}
 
 	d.styles.normal = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
+		Foreground(activeTheme.Foreground).
 		Padding(0, 0, 0, 2).
 		MarginBottom(1)
 
 	d.styles.selected = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#000000")).
-		Background(lipgloss.Color("#00FF00")).
+		Foreground(activeTheme.Background).
+		Background(activeTheme.Accent).
 		Padding(0, 0, 0, 2).
 		MarginBottom(1)
 
+	d.styles.header = lipgloss.NewStyle().
+		Foreground(activeTheme.Accent).
+		Bold(true).
+		MarginTop(1)
+
 	return d
 }
 
@@ -37,6 +45,16 @@ func (d chatDelegate) Height() int {
 	return 3
 }
 
+// itemHeight is the number of lines Render draws for item: a project header
+// is a single line below its top margin, a chat its title and description
+// lines plus the row's margins.
+func (d chatDelegate) itemHeight(item list.Item) int {
+	if _, ok := item.(projectHeaderItem); ok {
+		return 1 + d.styles.header.GetVerticalMargins()
+	}
+	return 2 + d.styles.normal.GetVerticalMargins()
+}
+
 func (d chatDelegate) Spacing() int {
 	return 0
 }
@@ -46,13 +64,32 @@ func (d chatDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd {
 }
 
 func (d chatDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	if header, ok := listItem.(projectHeaderItem); ok {
+		fmt.Fprint(w, d.styles.header.Render(fmt.Sprintf("%s (%d)", header.Title(), header.count)))
+		return
+	}
+
 	i, ok := listItem.(chatItem)
 	if !ok {
 		return
 	}
 
 	title := i.Title()
+	if i.chat.Pinned {
+		title = "📌 " + title
+	}
+	if d.marked[i.chat.ID] {
+		title = "✓ " + title
+	}
 	desc := i.Description()
+	if isCompact(m.Width()) {
+		desc = i.compactDescription()
+	}
+	// leave room for the row's left padding
+	if width := m.Width() - 2; width > 0 {
+		title = ansi.Truncate(title, width, "…")
+		desc = ansi.Truncate(desc, width, "…")
+	}
 
 	str := fmt.Sprintf("%s\n%s", title, desc)
 
@@ -79,32 +116,139 @@ func (i chatItem) Description() string {
 		len(i.chat.Messages))
 }
 
+// compactDescription is Description shortened for narrow terminals. The
+// project is only named for pinned chats, since the rest are grouped under
+// their project's header.
+func (i chatItem) compactDescription() string {
+	desc := fmt.Sprintf("%s · %d msgs", i.chat.CreatedAt.Format("2006-01-02 15:04"), len(i.chat.Messages))
+	if i.chat.Pinned && i.chat.ProjectName != "" {
+		desc = i.chat.ProjectName + " · " + desc
+	}
+	return desc
+}
+
+// FilterValue is empty so project headers drop out of filtered results.
+func (i projectHeaderItem) FilterValue() string {
+	return ""
+}
+
+func (i projectHeaderItem) Title() string {
+	if i.pinned {
+		return "Pinned"
+	}
+	if i.project == "" {
+		return "No project"
+	}
+	return i.project
+}
+
+func (o chatSortOrder) next() chatSortOrder {
+	return (o + 1) % 3
+}
+
+func (o chatSortOrder) String() string {
+	switch o {
+	case sortChatsByName:
+		return "name"
+	case sortChatsByMessages:
+		return "message count"
+	}
+	return "date"
+}
+
+// sortChats orders chats in place; ties fall back to newest first.
+func sortChats(chats []Chat, order chatSortOrder) {
+	sort.SliceStable(chats, func(i, j int) bool {
+		a, b := chats[i], chats[j]
+		switch order {
+		case sortChatsByName:
+			if !strings.EqualFold(a.Name, b.Name) {
+				return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+			}
+		case sortChatsByMessages:
+			if len(a.Messages) != len(b.Messages) {
+				return len(a.Messages) > len(b.Messages)
+			}
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+}
+
+// chatListItems builds the chat list rows: the two sentinels at the top, then
+// pinned chats, then the rest grouped under a header per project. Projects
+// are listed alphabetically with chats that have no project last.
+func chatListItems(chats []Chat, order chatSortOrder) []list.Item {
+	groups := make(map[string][]Chat)
+	var projects []string
+	var pinned []Chat
+	for _, chat := range chats {
+		if chat.Pinned {
+			pinned = append(pinned, chat)
+			continue
+		}
+		if _, ok := groups[chat.ProjectName]; !ok {
+			projects = append(projects, chat.ProjectName)
+		}
+		groups[chat.ProjectName] = append(groups[chat.ProjectName], chat)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i] == "" || projects[j] == "" {
+			return projects[j] == ""
+		}
+		return strings.ToLower(projects[i]) < strings.ToLower(projects[j])
+	})
+
+	items := make([]list.Item, 0, len(chats)+len(projects)+3)
+	items = append(items, chatItem{Chat{Name: "Temporary Chat", ProjectName: ""}})
+	items = append(items, chatItem{Chat{Name: "Create New Chat", ProjectName: ""}})
+	if len(pinned) > 0 {
+		sortChats(pinned, order)
+		items = append(items, projectHeaderItem{count: len(pinned), pinned: true})
+		for _, chat := range pinned {
+			items = append(items, chatItem{chat})
+		}
+	}
+	for _, project := range projects {
+		group := groups[project]
+		sortChats(group, order)
+		items = append(items, projectHeaderItem{project: project, count: len(group)})
+		for _, chat := range group {
+			items = append(items, chatItem{chat})
+		}
+	}
+	return items
+}
+
 func (m *model) initializeChatList() error {
 	if err := os.MkdirAll(m.chatsFolderPath, 0755); err != nil {
 		return fmt.Errorf("failed to create chats directory: %w", err)
 	}
 
-	chats, err := loadChats(m.chatsFolderPath)
+	chats, unreadable, err := loadChats(m.chatsFolderPath)
 	if err != nil {
 		return fmt.Errorf("failed to load chats: %w", err)
 	}
-
-	items := make([]list.Item, 0, len(chats)+2)
-	items = append(items, chatItem{Chat{Name: "Temporary Chat", ProjectName: ""}})
-	items = append(items, chatItem{Chat{Name: "Create New Chat", ProjectName: ""}})
+	if len(unreadable) > 0 {
+		movedTo := quarantineUnreadableChats(m.chatsFolderPath, unreadable)
+		m.startupNotice = unreadableChatsNotice(unreadable, movedTo)
+	}
 	for _, chat := range chats {
-		items = append(items, chatItem{chat})
+		if chat.Draft != "" {
+			if m.drafts == nil {
+				m.drafts = map[string]string{}
+			}
+			m.drafts[chat.ID] = chat.Draft
+		}
 	}
 
-	delegate := newChatDelegate()
+	items := chatListItems(chats, m.chatSortOrder)
+
+	delegate := newChatDelegate(m.markedChats)
 	m.chatList = list.New(items, delegate, m.width, m.height-4)
 	m.chatList.Title = "Chat List"
 	m.chatList.SetShowStatusBar(false)
 	m.chatList.SetFilteringEnabled(true)
-	m.chatList.Styles.Title = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(lipgloss.Color("#666666")).
-		Padding(0, 1)
+	m.chatList.Styles.Title = headerStyle()
 
 	m.chatList.Styles.NoItems = lipgloss.NewStyle().Margin(1, 2)
 	m.chatList.SetSize(m.width, m.height-4)
@@ -112,6 +256,64 @@ func (m *model) initializeChatList() error {
 	return nil
 }
 
+// reloadChatList rebuilds the rows from disk, keeping the grouping and sort
+// order current after a chat is added, renamed or moved to another project.
+// The cursor stays on the chat with selectID when it is still listed.
+func (m *model) reloadChatList(selectID string) error {
+	chats, _, err := loadChats(m.chatsFolderPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chats: %w", err)
+	}
+	m.chatList.SetItems(chatListItems(chats, m.chatSortOrder))
+
+	if selectID == "" {
+		return nil
+	}
+	for i, item := range m.chatList.Items() {
+		if ci, ok := item.(chatItem); ok && ci.chat.ID == selectID {
+			m.chatList.Select(i)
+			break
+		}
+	}
+	return nil
+}
+
+// chatListHeader is the bar drawn above the chat list.
+func (m model) chatListHeader() string {
+	title := "Chat List (Enter to select, / to search, ESC to go back)"
+	if len(m.markedChats) > 0 {
+		title = fmt.Sprintf("Chat List (%d selected — d to delete, space to toggle)", len(m.markedChats))
+	}
+	return headerStyle().
+		MarginBottom(1).
+		Render(title)
+}
+
+// moveChatCursor moves the cursor by delta, stepping over project headers.
+func (m *model) moveChatCursor(delta int) {
+	items := m.chatList.VisibleItems()
+	for i := m.chatList.Index() + delta; i >= 0 && i < len(items); i += delta {
+		if _, ok := items[i].(projectHeaderItem); !ok {
+			m.chatList.Select(i)
+			return
+		}
+	}
+}
+
+// cycleChatSort switches to the next sort order and rebuilds the list.
+func (m *model) cycleChatSort() tea.Cmd {
+	selectID := ""
+	if item, ok := m.selectedChatItem(); ok {
+		selectID = item.chat.ID
+	}
+
+	m.chatSortOrder = m.chatSortOrder.next()
+	if err := m.reloadChatList(selectID); err != nil {
+		return func() tea.Msg { return errMsg(err) }
+	}
+	return m.showToast(fmt.Sprintf("Sorting chats by %s.", m.chatSortOrder))
+}
+
 func (m *model) updateChatList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -123,23 +325,70 @@ func (m *model) updateChatList(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if keyIsCtrlZ(msg) {
+			m.flushAutoSave()
 			return m, tea.Quit
 		}
 
 		switch keypress := msg.String(); keypress {
 		case "up", "k":
-			if m.chatList.Index() > 0 {
-				m.chatList.CursorUp()
+			if m.chatList.FilterState() == list.Filtering {
+				break
 			}
+			m.moveChatCursor(-1)
 			return m, nil
 
 		case "down", "j":
-			if m.chatList.Index() < len(m.chatList.Items())-1 {
-				m.chatList.CursorDown()
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			m.moveChatCursor(1)
+			return m, nil
+
+		case "home", "end", "G":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			// row 0 is a sentinel and the last row is always a chat
+			if keypress == "home" {
+				m.chatList.Select(0)
+			} else {
+				m.chatList.Select(len(m.chatList.VisibleItems()) - 1)
 			}
 			return m, nil
 
+		case "s":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			return m, m.cycleChatSort()
+
+		case "ctrl+f":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			m.openSemanticSearch()
+			return m, nil
+
+		case "e":
+			if m.editProjectSettings() {
+				return m, nil
+			}
+
+		case " ":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			m.toggleChatMark()
+			return m, nil
+
+		case "p":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			return m, m.togglePinnedChat()
+
 		case "esc":
+			clear(m.markedChats)
 			m.viewMode = ChatView
 			return m, nil
 
@@ -158,27 +407,50 @@ func (m *model) updateChatList(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.newChatForm = createNewChatForm(&m.newChatName, &m.newProjectName)
 					return m, nil
 				} else if chatItem.chat.Name == "Temporary Chat" {
-					// Create new temporary chat
-					tempChat := Chat{
-						ID:          "temp-" + uuid.New().String(),
-						Name:        "Temporary Chat",
-						ProjectName: "Temporary",
-						CreatedAt:   time.Now(),
-						Messages:    make([]map[string]string, 0),
-					}
-					m.selectedChat = &tempChat
-					m.conversationHistory = tempChat.Messages
-					m.viewMode = ChatView
-					m.updateViewport()
+					tempChat := newTemporaryChat()
+					m.handleChatSelection(&tempChat)
 					return m, nil
 				}
 
-				m.selectedChat = &chatItem.chat
-				m.conversationHistory = chatItem.chat.Messages
-				m.viewMode = ChatView
-				m.updateViewport()
+				m.handleChatSelection(&chatItem.chat)
 				return m, nil
 			}
+
+		case "r":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			item, ok := m.selectedChatItem()
+			if !ok {
+				return m, nil
+			}
+			m.chatToRename = item.chat.ID
+			m.newChatName = item.chat.Name
+			m.newProjectName = item.chat.ProjectName
+			m.newChatForm = createNewChatForm(&m.newChatName, &m.newProjectName)
+			m.viewMode = RenameChatFormView
+			m.formActive = true
+			return m, nil
+
+		case "d":
+			if m.chatList.FilterState() == list.Filtering {
+				break
+			}
+			if len(m.markedChats) > 0 {
+				m.confirmDeleteType = "chats"
+				m.confirmForm = createConfirmForm(fmt.Sprintf("Are you sure you want to delete %d selected chat(s)? This action cannot be undone.", len(m.markedChats)), &m.confirmResult)
+				m.viewMode = ConfirmDelete
+				return m, nil
+			}
+			item, ok := m.selectedChatItem()
+			if !ok {
+				return m, nil
+			}
+			m.chatToDelete = item.chat.ID
+			m.confirmDeleteType = "chat"
+			m.confirmForm = createConfirmForm(fmt.Sprintf("Are you sure you want to delete chat '%s'? This action cannot be undone.", item.chat.Name), &m.confirmResult)
+			m.viewMode = ConfirmDelete
+			return m, nil
 		}
 	}
 
@@ -193,35 +465,286 @@ func (m *model) createNewChat(name string, projectName string) error {
 		return fmt.Errorf("failed to save new chat: %w", err)
 	}
 
-	m.chatList.InsertItem(1, chatItem{chat})
+	if err := m.reloadChatList(chat.ID); err != nil {
+		return err
+	}
+
+	m.handleChatSelection(&chat)
+
+	return nil
+}
+
+// selectedChatItem returns the highlighted chat, ignoring the
+// "Temporary Chat" and "Create New Chat" sentinel rows.
+func (m *model) selectedChatItem() (chatItem, bool) {
+	item, ok := m.chatList.SelectedItem().(chatItem)
+	if !ok || item.chat.ID == "" {
+		return chatItem{}, false
+	}
+	return item, true
+}
+
+// toggleChatMark adds the highlighted chat to the bulk-delete selection, or
+// removes it. Sentinel rows and project headers can't be marked.
+func (m *model) toggleChatMark() {
+	item, ok := m.selectedChatItem()
+	if !ok {
+		return
+	}
+	if m.markedChats[item.chat.ID] {
+		delete(m.markedChats, item.chat.ID)
+	} else {
+		m.markedChats[item.chat.ID] = true
+	}
+}
+
+func (m *model) deleteChat(chatID string) error {
+	if err := deleteChatFile(chatID, m.chatsFolderPath); err != nil {
+		return err
+	}
+	m.forgetChat(chatID)
+
+	return m.reloadChatListAfterDelete()
+}
+
+// deleteMarkedChats removes every marked chat file and reports how many were
+// deleted. Chats that couldn't be deleted stay marked so they can be retried.
+func (m *model) deleteMarkedChats() tea.Cmd {
+	ids := make([]string, 0, len(m.markedChats))
+	for id := range m.markedChats {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	deleted, failed := 0, 0
+	for _, id := range ids {
+		if err := deleteChatFile(id, m.chatsFolderPath); err != nil {
+			log.Printf("Failed to delete chat %s: %v", id, err)
+			failed++
+			continue
+		}
+		m.forgetChat(id)
+		deleted++
+	}
+
+	if err := m.reloadChatListAfterDelete(); err != nil {
+		return func() tea.Msg { return errMsg(err) }
+	}
+	if failed > 0 {
+		return m.showToast(fmt.Sprintf("Deleted %d chat(s); %d could not be deleted, see the log.", deleted, failed))
+	}
+	return m.showToast(fmt.Sprintf("Deleted %d chat(s).", deleted))
+}
+
+// forgetChat drops the state kept for a deleted chat, switching to a new
+// temporary chat if it was open.
+func (m *model) forgetChat(chatID string) {
+	delete(m.drafts, chatID)
+	delete(m.markedChats, chatID)
+	if m.selectedChat != nil && m.selectedChat.ID == chatID {
+		tempChat := newTemporaryChat()
+		m.selectedChat = &tempChat
+		m.conversationHistory = tempChat.Messages
+		m.textarea.Reset()
+		m.updateViewport()
+	}
+}
+
+// reloadChatListAfterDelete rebuilds the list, keeping the cursor near where
+// it was but off the project headers.
+func (m *model) reloadChatListAfterDelete() error {
+	index := m.chatList.Index()
+	if err := m.reloadChatList(""); err != nil {
+		return err
+	}
+	if index >= len(m.chatList.Items()) {
+		index = len(m.chatList.Items()) - 1
+	}
+	m.chatList.Select(index)
+	if _, ok := m.chatList.SelectedItem().(projectHeaderItem); ok {
+		m.moveChatCursor(1)
+		if _, ok := m.chatList.SelectedItem().(projectHeaderItem); ok {
+			m.moveChatCursor(-1)
+		}
+	}
+
+	return nil
+}
+
+func (m *model) renameChat(chatID string, name string, projectName string) error {
+	var chat Chat
+	if m.selectedChat != nil && m.selectedChat.ID == chatID {
+		m.selectedChat.Name = name
+		m.selectedChat.ProjectName = projectName
+		m.selectedChat.Messages = m.conversationHistory
+		chat = *m.selectedChat
+	} else {
+		// reload from disk so stale list copies don't overwrite newer messages
+		loaded, err := loadChat(chatID, m.chatsFolderPath)
+		if err != nil {
+			return err
+		}
+		loaded.Name = name
+		loaded.ProjectName = projectName
+		chat = loaded
+	}
+
+	if err := saveChat(chat, m.chatsFolderPath); err != nil {
+		return fmt.Errorf("failed to save renamed chat: %w", err)
+	}
+
+	return m.reloadChatList(chatID)
+}
+
+// togglePinnedChat pins the highlighted chat to the top of the list, or unpins
+// it, and keeps the cursor on it as it moves.
+func (m *model) togglePinnedChat() tea.Cmd {
+	item, ok := m.selectedChatItem()
+	if !ok {
+		return nil
+	}
+
+	var chat Chat
+	if m.selectedChat != nil && m.selectedChat.ID == item.chat.ID {
+		m.selectedChat.Pinned = !m.selectedChat.Pinned
+		m.selectedChat.Messages = m.conversationHistory
+		chat = *m.selectedChat
+	} else {
+		// reload from disk so stale list copies don't overwrite newer messages
+		loaded, err := loadChat(item.chat.ID, m.chatsFolderPath)
+		if err != nil {
+			return func() tea.Msg { return errMsg(err) }
+		}
+		loaded.Pinned = !loaded.Pinned
+		chat = loaded
+	}
+
+	if err := saveChat(chat, m.chatsFolderPath); err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to save pinned chat: %w", err)) }
+	}
+	if err := m.reloadChatList(chat.ID); err != nil {
+		return func() tea.Msg { return errMsg(err) }
+	}
+
+	if chat.Pinned {
+		return m.showToast(fmt.Sprintf("Pinned '%s'.", chat.Name))
+	}
+	return m.showToast(fmt.Sprintf("Unpinned '%s'.", chat.Name))
+}
+
+// promoteTemporaryChat saves the current temporary conversation as a real
+// chat so later saveCurrentChat calls persist it.
+func (m *model) promoteTemporaryChat(name string, projectName string) error {
+	chat := createNewChat(name, projectName)
+	chat.Messages = m.conversationHistory
+
+	if err := saveChat(chat, m.chatsFolderPath); err != nil {
+		return fmt.Errorf("failed to save chat: %w", err)
+	}
 
+	if err := m.reloadChatList(chat.ID); err != nil {
+		return err
+	}
 	m.selectedChat = &chat
-	m.conversationHistory = []map[string]string{}
-	m.viewMode = ChatView
 
 	return nil
 }
 
-func loadChats(folderPath string) ([]Chat, error) {
+// revealChatFile shows where the current chat is stored and opens its folder
+// in the file manager.
+func (m *model) revealChatFile() tea.Cmd {
+	if m.isTemporaryChat() {
+		return m.showToast("This temporary chat isn't saved to disk; press s to save it.")
+	}
+
+	path, err := filepath.Abs(filepath.Join(m.chatsFolderPath, m.selectedChat.ID+".json"))
+	if err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to resolve chat path: %w", err)) }
+	}
+
+	return tea.Batch(m.showToast(path), func() tea.Msg {
+		if err := openInFileManager(filepath.Dir(path)); err != nil {
+			return notifyMsg(fmt.Sprintf("%s (couldn't open a file manager: %v)", path, err))
+		}
+		return nil
+	})
+}
+
+func (m *model) isTemporaryChat() bool {
+	return m.selectedChat == nil || strings.HasPrefix(m.selectedChat.ID, "temp-")
+}
+
+func (m *model) hasUnsavedTemporaryChat() bool {
+	return m.isTemporaryChat() && len(m.conversationHistory) > 0
+}
+
+// confirmDiscardTemporaryChat asks before an action ("list" or "quit") that
+// would lose the temporary conversation, offering to save it first.
+func (m *model) confirmDiscardTemporaryChat(action string) {
+	m.discardAction = action
+	m.confirmDeleteType = "discard"
+	m.confirmForm = createConfirmForm("This temporary chat hasn't been saved and will be lost. Save it first?", &m.confirmResult)
+	m.viewMode = ConfirmDelete
+	m.textarea.Blur()
+}
+
+// completeDiscardAction carries out the action that was waiting on the
+// discard confirmation.
+func (m *model) completeDiscardAction() tea.Cmd {
+	action := m.discardAction
+	m.discardAction = ""
+
+	switch action {
+	case "quit":
+		return tea.Quit
+	case "list":
+		m.viewMode = ChatListView
+		return triggerWindowResize(m.width, m.height)
+	}
+	return nil
+}
+
+func loadChat(chatID string, folderPath string) (Chat, error) {
+	var chat Chat
+
+	data, err := os.ReadFile(filepath.Join(folderPath, chatID+".json"))
+	if err != nil {
+		return chat, fmt.Errorf("failed to read chat file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return chat, fmt.Errorf("failed to unmarshal chat: %w", err)
+	}
+
+	return chat, nil
+}
+
+// loadChats reads every chat in folderPath, pinned chats first and then newest
+// first. Files that can't be read or parsed are skipped and returned by name
+// in unreadable.
+func loadChats(folderPath string) (chats []Chat, unreadable []string, err error) {
 	if err := os.MkdirAll(folderPath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create chats directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to create chats directory: %w", err)
 	}
 
-	var chats []Chat
 	files, err := os.ReadDir(folderPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read chats directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to read chats directory: %w", err)
 	}
 
 	for _, file := range files {
 		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
 			data, err := os.ReadFile(filepath.Join(folderPath, file.Name()))
 			if err != nil {
+				log.Printf("Skipping unreadable chat file %s: %v", file.Name(), err)
+				unreadable = append(unreadable, file.Name())
 				continue
 			}
 
 			var chat Chat
 			if err := json.Unmarshal(data, &chat); err != nil {
+				log.Printf("Skipping corrupt chat file %s: %v", file.Name(), err)
+				unreadable = append(unreadable, file.Name())
 				continue
 			}
 			chats = append(chats, chat)
@@ -229,10 +752,41 @@ func loadChats(folderPath string) ([]Chat, error) {
 	}
 
 	sort.Slice(chats, func(i, j int) bool {
+		if chats[i].Pinned != chats[j].Pinned {
+			return chats[i].Pinned
+		}
 		return chats[i].CreatedAt.After(chats[j].CreatedAt)
 	})
 
-	return chats, nil
+	return chats, unreadable, nil
+}
+
+// quarantineUnreadableChats moves chat files that failed to load into a
+// corrupt/ subfolder, so they stop failing on every start but can still be
+// repaired. It returns the subfolder, or "" if any file couldn't be moved.
+func quarantineUnreadableChats(folderPath string, names []string) string {
+	corruptDir := filepath.Join(folderPath, "corrupt")
+	if err := os.MkdirAll(corruptDir, 0755); err != nil {
+		log.Printf("Failed to create %s: %v", corruptDir, err)
+		return ""
+	}
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(folderPath, name), filepath.Join(corruptDir, name)); err != nil {
+			log.Printf("Failed to move corrupt chat file %s: %v", name, err)
+			return ""
+		}
+	}
+	return corruptDir
+}
+
+// unreadableChatsNotice describes the chat files that failed to load and,
+// unless movedTo is empty, where they were moved.
+func unreadableChatsNotice(names []string, movedTo string) string {
+	notice := fmt.Sprintf("%d chat file(s) could not be loaded: %s", len(names), strings.Join(names, ", "))
+	if movedTo != "" {
+		notice += fmt.Sprintf(" (moved to %s)", movedTo)
+	}
+	return notice
 }
 
 func saveChat(chat Chat, folderPath string) error {
@@ -242,13 +796,82 @@ func saveChat(chat Chat, folderPath string) error {
 	}
 
 	filename := filepath.Join(folderPath, chat.ID+".json")
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := writeFileAtomic(filename, data, 0644); err != nil {
 		return fmt.Errorf("failed to write chat file: %w", err)
 	}
 
 	return nil
 }
 
+// exportFileName derives a markdown file name like "my-chat-20241105-153000.md".
+func exportFileName(chat Chat) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, chat.Name)
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		slug = "chat"
+	}
+
+	return fmt.Sprintf("%s-%s.md", slug, time.Now().Format("20060102-150405"))
+}
+
+func exportChatMarkdown(chat Chat, history []map[string]string, path string) error {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString(fmt.Sprintf("chat: %q\n", chat.Name))
+	b.WriteString(fmt.Sprintf("project: %q\n", chat.ProjectName))
+	b.WriteString(fmt.Sprintf("created: %s\n", chat.CreatedAt.Format(time.RFC3339)))
+	b.WriteString("---\n\n")
+	b.WriteString(conversationMarkdown(history))
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return nil
+}
+
+func exportChatCmd(chat Chat, history []map[string]string, path string) tea.Cmd {
+	return func() tea.Msg {
+		if err := exportChatMarkdown(chat, history, path); err != nil {
+			return errMsg(err)
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		return notifyMsg(fmt.Sprintf("Conversation exported to %s", absPath))
+	}
+}
+
+func deleteChatFile(chatID string, folderPath string) error {
+	filename := filepath.Join(folderPath, chatID+".json")
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete chat file: %w", err)
+	}
+	// the embeddings can be rebuilt, so failing to remove them isn't fatal
+	if err := os.Remove(embeddingsPath(folderPath, chatID)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to delete embeddings for chat %s: %v", chatID, err)
+	}
+
+	return nil
+}
+
+func newTemporaryChat() Chat {
+	return Chat{
+		ID:          "temp-" + uuid.New().String(),
+		Name:        "Temporary Chat",
+		ProjectName: "Temporary",
+		CreatedAt:   time.Now(),
+		Messages:    make([]map[string]string, 0),
+	}
+}
+
 func createNewChat(name string, projectName string) Chat {
 	return Chat{
 		ID:          uuid.New().String(),
@@ -259,14 +882,66 @@ func createNewChat(name string, projectName string) Chat {
 	}
 }
 
+// handleChatSelection switches to chat, keeping the unsent draft of the chat
+// being left and restoring the one saved for chat.
 func (m *model) handleChatSelection(chat *Chat) {
+	m.stashDraft()
 	m.selectedChat = chat
 	m.conversationHistory = chat.Messages
+	m.textarea.SetValue(m.drafts[chat.ID])
 	m.viewMode = ChatView
 	m.updateViewport()
 }
 
+// stashDraft remembers the textarea content for the current chat and saves it
+// with the chat, so it is restored after a restart. Temporary chats are gone
+// once left, so their drafts aren't kept, and neither is the text of a message
+// being edited.
+func (m *model) stashDraft() {
+	if m.selectedChat == nil || m.isTemporaryChat() || m.editingMessage >= 0 {
+		return
+	}
+	if m.drafts == nil {
+		m.drafts = map[string]string{}
+	}
+	if draft := m.textarea.Value(); strings.TrimSpace(draft) != "" {
+		m.drafts[m.selectedChat.ID] = draft
+	} else {
+		delete(m.drafts, m.selectedChat.ID)
+	}
+
+	if m.selectedChat.Draft != m.drafts[m.selectedChat.ID] {
+		if err := m.saveCurrentChat(); err != nil {
+			log.Printf("Failed to save draft: %v", err)
+		}
+	}
+}
+
+// scheduleAutoSave saves the current chat once autoSaveDelay passes without
+// another change, so bursts of updates cost one write.
+func (m *model) scheduleAutoSave() tea.Cmd {
+	m.autoSaveID++
+	m.autoSavePending = true
+	id := m.autoSaveID
+	return tea.Tick(autoSaveDelay, func(time.Time) tea.Msg {
+		return autoSaveMsg(id)
+	})
+}
+
+// flushAutoSave writes a pending auto-save right away, e.g. before quitting.
+func (m *model) flushAutoSave() {
+	if !m.autoSavePending {
+		return
+	}
+	if err := m.saveCurrentChat(); err != nil {
+		log.Printf("Failed to save chat: %v", err)
+	}
+}
+
 func (m *model) saveCurrentChat() error {
+	// any save covers what a scheduled auto-save would have written
+	m.autoSavePending = false
+
 	if m.selectedChat == nil {
 		return fmt.Errorf("no chat selected")
 	}
@@ -276,6 +951,7 @@ func (m *model) saveCurrentChat() error {
 	}
 
 	m.selectedChat.Messages = m.conversationHistory
+	m.selectedChat.Draft = m.drafts[m.selectedChat.ID]
 
 	return saveChat(*m.selectedChat, m.chatsFolderPath)
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m *model) lastAssistantMessage() (string, bool) {
+	for i := len(m.conversationHistory) - 1; i >= 0; i-- {
+		if m.conversationHistory[i]["role"] == "assistant" {
+			return m.conversationHistory[i]["content"], true
+		}
+	}
+	return "", false
+}
+
+func copyToClipboardCmd(text string, description string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err != nil {
+			return errMsg(fmt.Errorf("failed to copy to clipboard: %w", err))
+		}
+		return notifyMsg(fmt.Sprintf("Copied %s to the clipboard.", description))
+	}
+}
+
+// copyLastResponse copies the most recent assistant message, or only its last
+// fenced code block when codeOnly is set.
+func (m *model) copyLastResponse(codeOnly bool) tea.Cmd {
+	response, ok := m.lastAssistantMessage()
+	if !ok {
+		return func() tea.Msg { return notifyMsg("No response to copy yet.") }
+	}
+
+	if !codeOnly {
+		return copyToClipboardCmd(response, "the last response")
+	}
+
+	blocks := extractCodeBlocks(response)
+	if len(blocks) == 0 {
+		return func() tea.Msg { return notifyMsg("The last response has no code blocks.") }
+	}
+	return copyToClipboardCmd(blocks[len(blocks)-1].Code, "the last code block")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+func saveConfig(m *model) error {
+	data, err := json.MarshalIndent(m.config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	err = writeFileAtomic(m.configFilePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write config to file: %w", err)
+	}
+
+	return nil
+}
+
+func loadConfig(m *model) error {
+	if _, err := os.Stat(m.configFilePath); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.configFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// decode on top of the current defaults so missing fields keep them
+	loadedConfig := m.config
+	err = json.Unmarshal(data, &loadedConfig)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if _, ok := parsePositiveInt(loadedConfig.Tokens); !ok {
+		log.Printf("Invalid token limit %q in %s, using %s", loadedConfig.Tokens, m.configFilePath, defaultTokens)
+		loadedConfig.Tokens = defaultTokens
+	}
+
+	m.config = loadedConfig
+
+	return nil
+}
+
+// reconcileDefaultModel makes sure the saved default model is still installed,
+// falling back to the first installed model. It returns a notice when a saved
+// default had to be replaced.
+func (m *model) reconcileDefaultModel() string {
+	if len(m.availableModelVersions) == 0 {
+		return ""
+	}
+	for _, version := range m.availableModelVersions {
+		if version == m.config.ModelVersion {
+			return ""
+		}
+	}
+
+	previous := m.config.ModelVersion
+	m.config.ModelVersion = m.availableModelVersions[0]
+	if err := saveConfig(m); err != nil {
+		log.Printf("Failed to save config: %v", err)
+	}
+
+	if previous == "" {
+		return ""
+	}
+	return fmt.Sprintf("Default model %s is no longer installed, using %s instead.", previous, m.config.ModelVersion)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const contextTruncatedMarker = "\n[context truncated]"
+
+// loadFileContext reads an agent's context from a single file, every file
+// under a directory, or the files matching a glob. Multiple files are joined
+// with a header naming each one, and the result is cut off at
+// maxContextBytes.
+func loadFileContext(path string) (string, error) {
+	path = expandPath(path)
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		return truncateContext(string(content)), nil
+	}
+
+	files, err := contextFiles(path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", file, err)
+		}
+		// binary files would only waste the context window
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "--- %s ---\n%s\n\n", file, strings.TrimRight(string(content), "\n"))
+		if b.Len() > maxContextBytes {
+			break
+		}
+	}
+
+	return truncateContext(strings.TrimRight(b.String(), "\n")), nil
+}
+
+// contextFiles lists the regular files a context path refers to, in a stable
+// order. Directories are walked recursively, skipping hidden entries.
+func contextFiles(path string) ([]string, error) {
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+
+		var files []string
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no files found in %s", path)
+		}
+		return files, nil
+	}
+
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid context pattern %s: %w", path, err)
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// expandPath expands $VAR and ${VAR} references and a leading ~ for the home
+// directory, so context paths can be written the way they would be in a
+// shell. A ~ is left alone if the home directory can't be determined.
+func expandPath(path string) string {
+	path = os.ExpandEnv(strings.TrimSpace(path))
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func truncateContext(content string) string {
+	if len(content) <= maxContextBytes {
+		return content
+	}
+
+	cut := maxContextBytes
+	// back up to a rune boundary so the marker isn't glued to half a character
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut] + contextTruncatedMarker
+}
+
+// validateContextPath checks that a context path names a file, a directory
+// with files in it, or a glob matching at least one file.
+func validateContextPath(path string) error {
+	if _, err := contextFiles(expandPath(path)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// isTextFile reports whether path looks like text rather than binary data,
+// judging by whether its first few kilobytes contain a NUL byte.
+func isTextFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 8192)
+	n, err := f.Read(buf)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return bytes.IndexByte(buf[:n], 0) < 0, nil
+}
+
+// withAttachedFiles prefixes message with the contents of the text files
+// attached to it, each under a header naming the file, and returns the file
+// names for display. The files are sent with this message only.
+func withAttachedFiles(message string, paths []string) (string, []string, error) {
+	if len(paths) == 0 {
+		return message, nil, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Attached files:\n\n")
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		content, err := loadFileContext(path)
+		if err != nil {
+			return "", nil, err
+		}
+		fmt.Fprintf(&b, "--- %s ---\n%s\n\n", filepath.Base(path), strings.TrimRight(content, "\n"))
+		names = append(names, filepath.Base(path))
+	}
+	b.WriteString(message)
+	return b.String(), names, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("NOTES_DIR", "/srv/notes")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "tilde only", path: "~", want: home},
+		{name: "tilde prefixed", path: "~/notes/context.md", want: filepath.Join(home, "notes", "context.md")},
+		{name: "env var", path: "$NOTES_DIR/context.md", want: "/srv/notes/context.md"},
+		{name: "braced env var", path: "${NOTES_DIR}/*.md", want: "/srv/notes/*.md"},
+		{name: "env var holding tilde", path: "$HOME/context.md", want: filepath.Join(home, "context.md")},
+		{name: "tilde user form untouched", path: "~alice/context.md", want: "~alice/context.md"},
+		{name: "absolute path", path: "/tmp/context.md", want: "/tmp/context.md"},
+		{name: "surrounding spaces", path: "  ~/context.md ", want: filepath.Join(home, "context.md")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandPath(tt.path); got != tt.want {
+				t.Errorf("expandPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileContextExpandsTilde(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "context.md"), []byte("remember the milk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateContextPath("~/context.md"); err != nil {
+		t.Fatalf("validateContextPath: %v", err)
+	}
+	got, err := loadFileContext("~/context.md")
+	if err != nil {
+		t.Fatalf("loadFileContext: %v", err)
+	}
+	if got != "remember the milk" {
+		t.Errorf("loadFileContext = %q, want %q", got, "remember the milk")
+	}
+}
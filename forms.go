@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/huh"
@@ -45,10 +47,203 @@ func createNewChatForm(name *string, projectName *string) *huh.Form {
 	return form
 }
 
-func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool) *huh.Form {
+func createExportForm(fileName *string) *huh.Form {
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Export File Name").
+				Placeholder("conversation.md").
+				Value(fileName).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("file name cannot be empty")
+					}
+					return nil
+				}),
+		),
+	).WithShowHelp(true)
+	return form
+}
+
+func createPullModelForm(name *string) *huh.Form {
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Model Name").
+				Description("Pulled directly from the Ollama registry").
+				Placeholder("llama3.2:3b").
+				Value(name).
+				Validate(func(s string) error {
+					s = strings.TrimSpace(s)
+					if s == "" {
+						return fmt.Errorf("model name cannot be empty")
+					}
+					if strings.ContainsAny(s, " \t") {
+						return fmt.Errorf("model name cannot contain spaces")
+					}
+					return nil
+				}),
+		),
+	).WithShowHelp(true)
+	return form
+}
+
+// createModelSwitchForm picks a new model for the agent with role, and
+// whether to save it to the agent or use it for this session only.
+func createModelSwitchForm(role string, modelVersions []string, version *string, save *bool) *huh.Form {
+	options := make([]huh.Option[string], 0, len(modelVersions))
+	for _, mv := range modelVersions {
+		options = append(options, huh.NewOption(mv, mv))
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("Model for %s", role)).
+				Options(options...).
+				Value(version),
+
+			huh.NewSelect[bool]().
+				Title("Keep It").
+				Options(
+					huh.NewOption("For this session only", false),
+					huh.NewOption("Save to the agent", true),
+				).
+				Value(save),
+		),
+	).WithShowHelp(true)
+	return form
+}
+
+// createAgentToolsForm is a checklist of the available tools for one agent,
+// a quicker way to change them than the full agent form.
+func createAgentToolsForm(role string, availableTools []Tool, selected *[]string) *huh.Form {
+	options := make([]huh.Option[string], 0, len(availableTools))
+	for _, tool := range availableTools {
+		options = append(options, huh.NewOption(tool.Name, tool.Name))
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(fmt.Sprintf("Tools for %s", role)).
+				Description("space to toggle, enter to save").
+				Options(options...).
+				Value(selected),
+		),
+	).WithShowHelp(true)
+	return form
+}
+
+func createModelfileForm(name *string, path *string) *huh.Form {
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Model Name").
+				Description("Name for the model built from the Modelfile").
+				Placeholder("my-assistant:latest").
+				Value(name).
+				Validate(func(s string) error {
+					s = strings.TrimSpace(s)
+					if s == "" {
+						return fmt.Errorf("model name cannot be empty")
+					}
+					if strings.ContainsAny(s, " \t") {
+						return fmt.Errorf("model name cannot contain spaces")
+					}
+					return nil
+				}),
+
+			huh.NewInput().
+				Title("Modelfile").
+				Placeholder("/path/to/Modelfile").
+				Value(path).
+				Validate(func(s string) error {
+					s = strings.TrimSpace(s)
+					if s == "" {
+						return fmt.Errorf("Modelfile path cannot be empty")
+					}
+					info, err := os.Stat(s)
+					if err != nil {
+						return fmt.Errorf("file not found: %s", s)
+					}
+					if info.IsDir() {
+						return fmt.Errorf("%s is a directory", s)
+					}
+					return nil
+				}),
+		),
+	).WithShowHelp(true)
+	form.NextField()
+	form.PrevField()
+	return form
+}
+
+func createAgentExportForm(path *string, scope *string) *huh.Form {
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Agents to Export").
+				Options(
+					huh.NewOption("All agents", "all"),
+					huh.NewOption("Selected agent", "selected"),
+				).
+				Value(scope),
+
+			huh.NewInput().
+				Title("Export File").
+				Placeholder("agents-export.json").
+				Value(path).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("file path cannot be empty")
+					}
+					return nil
+				}),
+		),
+	).WithShowHelp(true)
+	form.NextField()
+	form.PrevField()
+	return form
+}
+
+func createAgentImportForm(path *string, strategy *string) *huh.Form {
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Import File").
+				Placeholder("/path/to/agents.json").
+				Value(path).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("file path cannot be empty")
+					}
+					if _, err := os.Stat(s); err != nil {
+						return fmt.Errorf("file not found: %s", s)
+					}
+					return nil
+				}),
+
+			huh.NewSelect[string]().
+				Title("On Duplicate Role").
+				Options(
+					huh.NewOption("Skip imported agent", "skip"),
+					huh.NewOption("Overwrite existing agent", "overwrite"),
+					huh.NewOption("Rename imported agent", "rename"),
+				).
+				Value(strategy),
+		),
+	).WithShowHelp(true)
+	form.NextField()
+	form.PrevField()
+	return form
+}
+
+func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool, takenRoles []string) *huh.Form {
 	if agent.SelectedTools == nil {
 		agent.SelectedTools = []string{}
 	}
+	agent.stopInput = strings.Join(agent.StopSequences, ", ")
 
 	modelOptions := make([]huh.Option[string], 0, len(modelVersions))
 	for _, mv := range modelVersions {
@@ -72,13 +267,37 @@ func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool
 			huh.NewInput().
 				Title("Role").
 				Placeholder("Enter a unique role identifier").
-				Value(&agent.Role),
+				Value(&agent.Role).
+				Validate(func(s string) error {
+					role := strings.TrimSpace(s)
+					if role == "" {
+						return fmt.Errorf("role cannot be empty")
+					}
+					for _, taken := range takenRoles {
+						if strings.EqualFold(role, taken) {
+							return fmt.Errorf("an agent with role '%s' already exists", taken)
+						}
+					}
+					return nil
+				}),
 
 			huh.NewSelect[string]().
 				Title("Model Version").
 				Options(modelOptions...).
 				Value(&agent.ModelVersion),
 
+			huh.NewInput().
+				Title("Fallback Model").
+				Description("Used instead when the model above isn't installed").
+				Placeholder("None").
+				Value(&agent.FallbackModel).
+				Validate(func(s string) error {
+					if s = strings.TrimSpace(s); s != "" && s == agent.ModelVersion {
+						return fmt.Errorf("must differ from the agent's model")
+					}
+					return nil
+				}),
+
 			huh.NewText().
 				Title("System Prompt").
 				Value(&agent.SystemPrompt),
@@ -95,13 +314,13 @@ func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool
 				Value(&agent.ContextFilePath).
 				TitleFunc(func() string {
 					if agent.UseContext {
-						return "Context File Path"
+						return "Context Path (file, directory or glob)"
 					}
 					return "Context Status"
 				}, &agent.UseContext).
 				PlaceholderFunc(func() string {
 					if agent.UseContext {
-						return "/path/to/your/context/file"
+						return "/path/to/context, /path/to/dir or /path/*.go"
 					}
 					return "No context file selected"
 				}, &agent.UseContext).
@@ -110,12 +329,9 @@ func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool
 						return nil
 					}
 					if s == "" {
-						return fmt.Errorf("context file path is required when context is enabled")
-					}
-					if _, err := os.Stat(s); err != nil {
-						return fmt.Errorf("file not found: %s", s)
+						return fmt.Errorf("context path is required when context is enabled")
 					}
-					return nil
+					return validateContextPath(s)
 				}),
 
 			huh.NewSelect[bool]().
@@ -126,11 +342,130 @@ func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool
 				).
 				Value(&agent.UseConversation),
 
+			huh.NewSelect[bool]().
+				Title("Input").
+				Description("Ignored for the first agent and for parallel agents, which always get the user message").
+				Options(
+					huh.NewOption("Previous agent's reply", true),
+					huh.NewOption("Original user message", false),
+				).
+				Value(&agent.UseChainInput),
+
 			huh.NewSelect[string]().
 				Title("Token Limit").
 				Options(tokenOptions...).
 				Value(&agent.Tokens),
 
+			huh.NewSelect[string]().
+				Title("When History Exceeds the Token Limit").
+				Options(
+					huh.NewOption("Drop the oldest messages", overflowTruncate),
+					huh.NewOption("Summarize the oldest messages", overflowSummarize),
+				).
+				Value(&agent.ContextOverflow),
+
+			huh.NewInput().
+				Title("Temperature").
+				Placeholder("Ollama default").
+				Value(&agent.Temperature).
+				Validate(validateOptionalFloat),
+
+			huh.NewInput().
+				Title("Top P").
+				Placeholder("Ollama default").
+				Value(&agent.TopP).
+				Validate(validateOptionalFloat),
+
+			huh.NewInput().
+				Title("Top K").
+				Placeholder("Ollama default").
+				Value(&agent.TopK).
+				Validate(validateOptionalInt),
+
+			huh.NewInput().
+				Title("Repeat Penalty").
+				Placeholder("Ollama default").
+				Value(&agent.RepeatPenalty).
+				Validate(validateOptionalFloat),
+
+			huh.NewInput().
+				Title("Max Output Tokens").
+				Description("-1 for unlimited").
+				Placeholder("Ollama default").
+				Value(&agent.MaxTokens).
+				Validate(func(s string) error {
+					if err := validateOptionalInt(s); err != nil {
+						return err
+					}
+					if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n < -1 {
+						return fmt.Errorf("must be -1 or greater")
+					}
+					return nil
+				}),
+
+			huh.NewInput().
+				Title("Stop Sequences").
+				Description("Comma-separated").
+				Placeholder("None").
+				Value(&agent.stopInput),
+
+			huh.NewSelect[bool]().
+				Title("Run in Parallel").
+				Description("Parallel agents receive the original user message alongside neighbouring parallel agents").
+				Options(
+					huh.NewOption("Yes", true),
+					huh.NewOption("No", false),
+				).
+				Value(&agent.Parallel),
+
+			huh.NewSelect[bool]().
+				Title("Force JSON Output").
+				Options(
+					huh.NewOption("Yes", true),
+					huh.NewOption("No", false),
+				).
+				Value(&agent.ForceJSON),
+
+			huh.NewText().
+				Title("JSON Schema").
+				Description("Optional schema the output must follow when JSON output is forced").
+				Placeholder("Any JSON").
+				Value(&agent.JSONSchema).
+				Validate(func(s string) error {
+					s = strings.TrimSpace(s)
+					if s == "" {
+						return nil
+					}
+					var schema map[string]interface{}
+					if err := json.Unmarshal([]byte(s), &schema); err != nil {
+						return fmt.Errorf("must be a JSON object: %v", err)
+					}
+					return nil
+				}),
+
+			huh.NewInput().
+				Title("Request Timeout (seconds)").
+				Description("How long to wait for each reply from the model").
+				Placeholder("Default (5 minutes)").
+				Value(&agent.TimeoutSeconds).
+				Validate(func(s string) error {
+					if err := validateOptionalInt(s); err != nil {
+						return err
+					}
+					if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n <= 0 {
+						return fmt.Errorf("must be greater than 0")
+					}
+					return nil
+				}),
+
+			huh.NewSelect[bool]().
+				Title("If This Agent Fails").
+				Options(
+					huh.NewOption("Stop the chain", false),
+					huh.NewOption("Skip it and continue", true),
+				).
+				Value(&agent.ContinueOnError),
+
 			huh.NewMultiSelect[string]().
 				Title("Tools").
 				Options(toolOptions...).
@@ -143,6 +478,99 @@ func createAgentForm(agent *Agent, modelVersions []string, availableTools []Tool
 	return form
 }
 
+func validateOptionalFloat(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	return nil
+}
+
+func validateOptionalInt(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if _, err := strconv.Atoi(s); err != nil {
+		return fmt.Errorf("must be a whole number")
+	}
+	return nil
+}
+
+func createConfigForm(config *ChatConfig, modelVersions []string) *huh.Form {
+	modelOptions := make([]huh.Option[string], 0, len(modelVersions))
+	for _, mv := range modelVersions {
+		modelOptions = append(modelOptions, huh.NewOption(mv, mv))
+	}
+
+	tokenOptions := []huh.Option[string]{
+		huh.NewOption("2048 tokens", "2048"),
+		huh.NewOption("4096 tokens", "4096"),
+		huh.NewOption("8192 tokens", "8192"),
+		huh.NewOption("16384 tokens", "16384"),
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Model Version").
+				Options(modelOptions...).
+				Value(&config.ModelVersion),
+
+			huh.NewText().
+				Title("System Prompt").
+				Value(&config.SystemPrompt),
+
+			huh.NewInput().
+				Title("Context Path (file, directory or glob)").
+				Placeholder("/path/to/context, /path/to/dir or /path/*.go").
+				Value(&config.ContextFilePath).
+				Validate(func(s string) error {
+					if s == "" {
+						return nil
+					}
+					return validateContextPath(s)
+				}),
+
+			huh.NewSelect[string]().
+				Title("Token Limit").
+				Options(tokenOptions...).
+				Value(&config.Tokens),
+
+			huh.NewSelect[string]().
+				Title("Markdown Style").
+				Description("Falls back to auto if the style can't be loaded").
+				Options(markdownStyleOptions(config.MarkdownStyle)...).
+				Value(&config.MarkdownStyle),
+		).Title(configFormTitle),
+	).WithShowHelp(true)
+	form.NextField()
+	form.PrevField()
+
+	return form
+}
+
+// markdownStyleOptions lists the built-in glamour styles, plus current when
+// it's a custom style file set in config.json so opening the form keeps it.
+func markdownStyleOptions(current string) []huh.Option[string] {
+	options := []huh.Option[string]{
+		huh.NewOption("Auto (follow terminal background)", ""),
+		huh.NewOption("Dark", "dark"),
+		huh.NewOption("Light", "light"),
+		huh.NewOption("Dracula", "dracula"),
+		huh.NewOption("No colors", "notty"),
+	}
+	switch current {
+	case "", "auto", "dark", "light", "dracula", "notty":
+	default:
+		options = append(options, huh.NewOption(current, current))
+	}
+	return options
+}
+
 func createConfirmForm(title string, confirmResult *bool) *huh.Form {
 	form := huh.NewForm(
 		huh.NewGroup(
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type keyHelp struct {
+	key    string
+	action string
+}
+
+var globalKeyHelp = []keyHelp{
+	{"?", "Toggle this help"},
+	{"esc", "Go back"},
+	{"home / end (G)", "Jump to first / last item"},
+	{"ctrl+z", "Exit application"},
+}
+
+func helpBindings(mode viewMode) []keyHelp {
+	switch mode {
+	case ChatView:
+		return []keyHelp{
+			{"i", "Write a message"},
+			{"l", "Open chat list"},
+			{"m", "Open model view"},
+			{"M", "Switch the agent's model"},
+			{"g", "Open agent view"},
+			{"c", "Chat configuration"},
+			{"f", "Attach an image"},
+			{"a", "Attach a text file as context for the next message"},
+			{"s", "Save temporary chat"},
+			{"x", "Export conversation to Markdown"},
+			{"w", "Show where the chat file is saved"},
+			{"ctrl+l", "Clear the conversation"},
+			{"R", "Regenerate last response"},
+			{"C", "Continue a cut-off last response"},
+			{"v", "Select messages to edit or delete"},
+			{"t", "Tool usage log"},
+			{"T", "Switch color theme"},
+			{"r", "Toggle raw / rendered markdown"},
+			{"y", "Copy last response"},
+			{"Y", "Copy last code block"},
+			{"p", "Preview the prompt for the draft message"},
+			{"/", "Search conversation"},
+			{"n / N", "Next / previous search match"},
+			{"o", "Toggle Ollama server"},
+			{"j / k", "Scroll down / up"},
+			{"ctrl+d / ctrl+u", "Half page down / up"},
+		}
+	case InsertView:
+		return []keyHelp{
+			{"enter", "Send message"},
+			{"esc", "Leave insert mode"},
+		}
+	case MessageSelectView:
+		return []keyHelp{
+			{"j / k", "Select next / previous message"},
+			{"e", "Edit selected message"},
+			{"d", "Delete selected message"},
+			{"y", "Copy selected message"},
+		}
+	case ChatListView:
+		return []keyHelp{
+			{"enter", "Open or create chat"},
+			{"/", "Search chats"},
+			{"r", "Rename chat"},
+			{"p", "Pin / unpin chat"},
+			{"space", "Select chat for bulk delete"},
+			{"d", "Delete chat (or all selected chats)"},
+			{"s", "Cycle sort: date / name / messages"},
+			{"ctrl+f", "Search all chats by meaning"},
+			{"e", "Edit project system prompt"},
+		}
+	case ModelView:
+		return []keyHelp{
+			{"enter", "Add new model / delete model"},
+			{"d", "Delete model"},
+			{"p", "Pull a model by name"},
+			{"n", "Create a model from a Modelfile"},
+			{"v", "View model details"},
+			{"o", "Toggle Ollama server"},
+			{"j / k", "Move down / up"},
+		}
+	case AvailableModelsView:
+		return []keyHelp{
+			{"enter", "Choose parameter size"},
+			{"r", "Refresh library cache"},
+			{"/", "Filter by name"},
+			{"pgup / pgdn", "Previous / next page"},
+		}
+	case ParameterSizesView:
+		return []keyHelp{
+			{"enter", "Choose quantization or download"},
+		}
+	case QuantizationView:
+		return []keyHelp{
+			{"enter", "Download model"},
+		}
+	case AgentView:
+		return []keyHelp{
+			{"enter", "Add / edit agent"},
+			{"a", "Add agent"},
+			{"e", "Edit agent"},
+			{"d", "Delete agent"},
+			{"c", "Clone agent"},
+			{"t", "Choose agent tools"},
+			{"u / y", "Move agent up / down"},
+			{"ctrl+u", "Undo last move"},
+			{"space", "Enable / disable agent"},
+			{"x", "Export agents"},
+			{"i", "Import agents"},
+			{"r", "Reload agents from disk"},
+			{"v", "View the agent chain"},
+			{"g", "Back to chat"},
+		}
+	case PromptPreviewView, ModelDetailView, AgentChainView:
+		return []keyHelp{
+			{"j / k", "Scroll down / up"},
+			{"ctrl+d / ctrl+u", "Half page down / up"},
+		}
+	case ToolUsageView:
+		return []keyHelp{
+			{"j / k", "Move down / up"},
+		}
+	case SemanticSearchView:
+		return []keyHelp{
+			{"j / k", "Move down / up"},
+			{"enter", "Open the chat at this message"},
+		}
+	}
+	return nil
+}
+
+// helpAvailable reports whether '?' should open the help overlay rather than
+// being typed into an input.
+func (m *model) helpAvailable() bool {
+	if m.formActive || m.agentFormActive || m.confirmForm != nil {
+		return false
+	}
+	switch m.viewMode {
+	case InsertView, DownloadingView, FilePickerView:
+		return false
+	case ChatListView:
+		return m.chatList.FilterState() != list.Filtering
+	}
+	return true
+}
+
+func (m model) helpView() string {
+	keyStyle := lipgloss.NewStyle().Foreground(activeTheme.Accent).Bold(true).Width(10)
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("Keybindings"))
+	b.WriteString("\n\n")
+	for _, binding := range append(helpBindings(m.viewMode), globalKeyHelp...) {
+		b.WriteString(fmt.Sprintf("%s %s\n", keyStyle.Render(binding.key), binding.action))
+	}
+
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Muted).
+		Padding(1, 2).
+		Render(strings.TrimRight(b.String(), "\n"))
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, panel)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpTransport is shared by every client so connections to Ollama are kept
+// alive and reused across the agent chain.
+var httpTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+var (
+	// httpClient is used for request/response calls. Non-streamed chat
+	// completions only return once generation finishes, so the overall
+	// timeout is generous.
+	httpClient = &http.Client{
+		Transport: httpTransport,
+		Timeout:   5 * time.Minute,
+	}
+
+	// streamingHTTPClient has no overall timeout, so long streamed bodies
+	// like model pulls aren't cut off; only connection setup is bounded by
+	// the transport.
+	streamingHTTPClient = &http.Client{
+		Transport: httpTransport,
+	}
+
+	// healthHTTPClient fails fast so checking whether Ollama is up never
+	// stalls the UI.
+	healthHTTPClient = &http.Client{
+		Transport: httpTransport,
+		Timeout:   2 * time.Second,
+	}
+)
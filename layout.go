@@ -0,0 +1,80 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// compactWidth is the terminal width below which views switch to a compact
+// layout: stacked instead of side-by-side elements, shorter descriptions and
+// help lines. Tables shrink to fit at any width.
+const compactWidth = 80
+
+// Column layouts at full width; fitTableColumns scales them down to fit
+// narrower terminals.
+var (
+	modelTableColumns = []table.Column{
+		{Title: "Name", Width: 30},
+		{Title: "Parameter Size", Width: 15},
+		{Title: "Size (GB)", Width: 10},
+	}
+	availableTableColumns = []table.Column{
+		{Title: "Available Models", Width: 30},
+		{Title: "Sizes", Width: 20},
+	}
+	agentTableColumns = []table.Column{
+		{Title: "Role", Width: 20},
+		{Title: "Model Version", Width: 40},
+		{Title: "Enabled", Width: 8},
+	}
+	toolUsageTableColumns = []table.Column{
+		{Title: "Time", Width: 19},
+		{Title: "Agent", Width: 15},
+		{Title: "Tool", Width: 15},
+		{Title: "Status", Width: 8},
+		{Title: "Details", Width: 40},
+	}
+	semanticTableColumns = []table.Column{
+		{Title: "Score", Width: 6},
+		{Title: "Chat", Width: 20},
+		{Title: "Role", Width: 10},
+		{Title: "Message", Width: 50},
+	}
+)
+
+// isCompact reports whether a view width calls for the compact layout. A
+// zero width means the size isn't known yet.
+func isCompact(width int) bool {
+	return width > 0 && width < compactWidth
+}
+
+// fitColumns returns columns unchanged when they fit in width, and otherwise
+// shrinks each in proportion to its full width. The table truncates cells
+// that no longer fit with an ellipsis.
+func fitColumns(columns []table.Column, width int) []table.Column {
+	// each cell is padded by one space on either side
+	available := width - 2*len(columns)
+	total := 0
+	for _, column := range columns {
+		total += column.Width
+	}
+	if width <= 0 || total <= available {
+		return columns
+	}
+
+	const minColumnWidth = 4
+	fitted := make([]table.Column, len(columns))
+	for i, column := range columns {
+		column.Width = max(column.Width*available/total, minColumnWidth)
+		fitted[i] = column
+	}
+	return fitted
+}
+
+// fitTableColumns sizes every multi-column table's columns to the window.
+func (m *model) fitTableColumns() {
+	m.modelTable.SetColumns(fitColumns(modelTableColumns, m.width))
+	m.availableTable.SetColumns(fitColumns(availableTableColumns, m.width))
+	m.agentsTable.SetColumns(fitColumns(agentTableColumns, m.width))
+	m.toolUsageTable.SetColumns(fitColumns(toolUsageTableColumns, m.width))
+	m.semanticTable.SetColumns(fitColumns(semanticTableColumns, m.width))
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -16,49 +19,54 @@ import (
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/google/uuid"
+	"github.com/charmbracelet/x/ansi"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
 func (m *model) Init() tea.Cmd {
-	return tea.Batch(
+	cmds := []tea.Cmd{
 		textarea.Blink,
 		tea.EnterAltScreen,
-		fetchModelsCmd(),
+		m.refreshModels(),
+		scheduleOllamaHealthCheck(m.healthCheckInterval()),
 		m.spinner.Tick,
-	)
+	}
+	if m.startupNotice != "" {
+		cmds = append(cmds, m.showToast(m.startupNotice))
+		m.startupNotice = ""
+	}
+	// nothing can be answered without an agent, so start by creating one
+	if len(m.agents) == 0 {
+		m.viewMode = AgentView
+		m.textarea.Blur()
+		m.agentsTable.Focus()
+	}
+	return tea.Batch(cmds...)
 }
 
-func InitialModel() *model {
+// InitialModel builds the model, keeping config, agents, chats and the other
+// state files in dataDir.
+func InitialModel(dataDir string) *model {
 	ta := setupTextarea()
 	vp := viewport.New(85, 20)
-	renderer, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(vp.Width),
-	)
+	renderer, _ := newRenderer(vp.Width, "")
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
-	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00"))
+	sp.Style = lipgloss.NewStyle().Foreground(activeTheme.Accent)
+
+	tableStyle := tableStyles()
 
-	tableStyle := table.DefaultStyles()
-	tableStyle.Header = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF"))
-	tableStyle.Selected = lipgloss.NewStyle().Foreground(lipgloss.Color("#000000")).Background(lipgloss.Color("#00FF00"))
+	prog := progress.New(progress.WithDefaultGradient())
 
 	fp := filepicker.New()
 	fp.CurrentDirectory, _ = os.Getwd()
-	fp.AllowedTypes = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+	fp.AllowedTypes = defaultAttachmentTypes
 	fp.Height = 10
 
-	modelColumns := []table.Column{
-		{Title: "Name", Width: 30},
-		{Title: "Parameter Size", Width: 15},
-		{Title: "Size (GB)", Width: 10},
-	}
-
 	modelTable := table.New(
-		table.WithColumns(modelColumns),
+		table.WithColumns(modelTableColumns),
 		table.WithFocused(false),
 		table.WithStyles(tableStyle),
 	)
@@ -66,13 +74,8 @@ func InitialModel() *model {
 		{"Add New Model", "N/A", "N/A"},
 	})
 
-	availableColumns := []table.Column{
-		{Title: "Available Models", Width: 30},
-		{Title: "Sizes", Width: 20},
-	}
-
 	availableTable := table.New(
-		table.WithColumns(availableColumns),
+		table.WithColumns(availableTableColumns),
 		table.WithFocused(false),
 		table.WithStyles(tableStyle),
 	)
@@ -85,20 +88,33 @@ func InitialModel() *model {
 		table.WithStyles(tableStyle),
 	)
 
-	agentColumns := []table.Column{
-		{Title: "Role", Width: 20},
-		{Title: "Model Version", Width: 40},
-	}
+	quantizationTable := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Tag", Width: 40},
+		}),
+		table.WithFocused(false),
+		table.WithStyles(tableStyle),
+	)
 
 	agentsTable := table.New(
-		table.WithColumns(agentColumns),
+		table.WithColumns(agentTableColumns),
 		table.WithFocused(false),
 		table.WithStyles(tableStyle),
 	)
 
-	availableTools := []Tool{
-		checkGoCodeTool,
-	}
+	toolUsageTable := table.New(
+		table.WithColumns(toolUsageTableColumns),
+		table.WithFocused(false),
+		table.WithStyles(tableStyle),
+	)
+
+	semanticTable := table.New(
+		table.WithColumns(semanticTableColumns),
+		table.WithFocused(false),
+		table.WithStyles(tableStyle),
+	)
+
+	registry := newToolRegistry()
 
 	m := &model{
 		userMessages:        make([]string, 0),
@@ -106,12 +122,17 @@ func InitialModel() *model {
 		conversationHistory: []map[string]string{},
 		currentUserMessage:  "",
 		textarea:            ta,
+		searchInput:         setupSearchInput(),
+		availableFilter:     setupAvailableFilterInput(),
 		viewport:            vp,
+		previewViewport:     viewport.New(85, 20),
 		modelTable:          modelTable,
 		availableTable:      availableTable,
 		parameterSizesTable: parameterSizesTable,
+		quantizationTable:   quantizationTable,
 		spinner:             sp,
 		renderer:            renderer,
+		rendererWidth:       vp.Width,
 		viewMode:            ChatView,
 		ollamaRunning:       false,
 		config: ChatConfig{
@@ -119,65 +140,85 @@ func InitialModel() *model {
 			SystemPrompt:    defaultSystemPrompt,
 			ContextFilePath: defaultContextFilePath,
 			Tokens:          defaultTokens,
+			CommandDenylist: defaultCommandDenylist,
 		},
 		formActive:             false,
 		agents:                 []Agent{},
 		agentsTable:            agentsTable,
 		agentViewMode:          ChatView,
 		agentFormActive:        false,
-		availableTools:         availableTools,
+		toolRegistry:           registry,
 		availableModelVersions: []string{},
 		modelsFetchError:       nil,
 		errorMessage:           "",
 		confirmDeleteType:      "",
 		toolUsages:             []ToolUsage{},
-		toolUsageFilePath:      "./tool_usages.json",
+		toolUsageFilePath:      filepath.Join(dataDir, toolUsageFileName),
+		agentsFilePath:         filepath.Join(dataDir, agentsFileName),
+		projectsFilePath:       filepath.Join(dataDir, projectsFileName),
+		configFilePath:         filepath.Join(dataDir, configFileName),
+		libraryCachePath:       filepath.Join(dataDir, libraryCacheFileName),
+		chatsFolderPath:        filepath.Join(dataDir, chatsFolderName),
+		toolUsageTable:         toolUsageTable,
+		semanticTable:          semanticTable,
 		filePicker:             fp,
 		selectedImage:          "",
+		downloadProgress:       prog,
+		editingMessage:         -1,
+		markedChats:            make(map[string]bool),
 	}
 
+	if err := loadConfig(m); err != nil {
+		log.Printf("Warning: %v, falling back to default config", err)
+	}
+	if m.config.MaxRetries > 0 {
+		maxRequestAttempts = m.config.MaxRetries
+	}
+
+	m.configureFilePicker()
+
+	// listed in the agent form; each chain gets its own copy from chainTools
+	m.toolRegistry.register(newRunCommandTool(nil, commandPolicy{}))
+	m.availableTools = m.toolRegistry.list()
+
 	err := loadAgents(m)
 	if err != nil {
 		log.Printf("Error loading agents from file: %v", err)
-		m.agents = append(m.agents, Agent{
-			Role:            "Assistant",
-			ModelVersion:    "",
-			SystemPrompt:    "",
-			UseContext:      false,
-			ContextFilePath: "",
-			UseConversation: false,
-			Tokens:          "2048",
-		})
-
-		err = saveAgents(m)
-		if err != nil {
-			log.Printf("Failed to save default agents: %v", err)
-		}
-		err := loadToolUsages(m)
-		if err != nil {
-			log.Printf("Error loading tool usages: %v", err)
+
+		// no default agent is created: Init opens the Agent view so the first
+		// one is set up with a model instead of failing at send time
+		var corrupt *corruptFileError
+		if errors.As(err, &corrupt) {
+			if moved, qerr := quarantineFile(corrupt.path); qerr == nil {
+				m.errorMessage = fmt.Sprintf("%v\n\nIt was moved to %s; add an agent to get started.", err, moved)
+			} else {
+				log.Printf("Failed to move corrupt agents file aside: %v", qerr)
+				m.errorMessage = fmt.Sprintf("%v\n\nFix or remove the file before adding agents, or they will replace it.", err)
+			}
 		}
 	}
 
+	if err := loadProjects(m); err != nil {
+		log.Printf("Error loading projects: %v", err)
+	}
+
+	if err := loadToolUsages(m); err != nil {
+		log.Printf("Error loading tool usages: %v", err)
+	}
+
 	m.populateAgentsTable()
+	m.populateToolUsageTable()
 
-	m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools)
+	m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools, m.agentRolesExcept(""))
 
 	m.availableModelVersions = []string{defaultModelVersion}
 
 	m.updateTextareaIndicatorColor()
 
-	tempChat := Chat{
-		ID:          "temp-" + uuid.New().String(),
-		Name:        "Temporary Chat",
-		ProjectName: "Temporary",
-		CreatedAt:   time.Now(),
-		Messages:    make([]map[string]string, 0),
-	}
+	tempChat := newTemporaryChat()
 	m.selectedChat = &tempChat
 	m.conversationHistory = tempChat.Messages
 
-	m.chatsFolderPath = "./chats"
 	if err = m.initializeChatList(); err != nil {
 		log.Printf("Error initializing chat list: %v", err)
 	}
@@ -185,6 +226,14 @@ func InitialModel() *model {
 	m.newProjectName = ""
 	m.newChatForm = createNewChatForm(&m.newChatName, &m.newProjectName)
 
+	if m.config.Theme != "" {
+		if theme, ok := themeByName(m.config.Theme); ok {
+			m.applyTheme(theme)
+		} else {
+			log.Printf("Unknown theme %q, using %s", m.config.Theme, activeTheme.Name)
+		}
+	}
+
 	return m
 }
 
@@ -224,20 +273,195 @@ func (m *model) navigate(direction string) {
 		} else if direction == "down" {
 			m.agentsTable.MoveDown(1)
 		}
+	case QuantizationView:
+		if direction == "up" {
+			m.quantizationTable.MoveUp(1)
+		} else if direction == "down" {
+			m.quantizationTable.MoveDown(1)
+		}
+	case ToolUsageView:
+		if direction == "up" {
+			m.toolUsageTable.MoveUp(1)
+		} else if direction == "down" {
+			m.toolUsageTable.MoveDown(1)
+		}
+	case SemanticSearchView:
+		if direction == "up" {
+			m.semanticTable.MoveUp(1)
+		} else if direction == "down" {
+			m.semanticTable.MoveDown(1)
+		}
 	case ChatView:
 		if direction == "up" {
 			m.viewport.LineUp(1)
 		} else if direction == "down" {
 			m.viewport.LineDown(1)
 		}
+	case PromptPreviewView, ModelDetailView, AgentChainView:
+		if direction == "up" {
+			m.previewViewport.LineUp(1)
+		} else if direction == "down" {
+			m.previewViewport.LineDown(1)
+		}
+	case MessageSelectView:
+		if direction == "up" && m.selectedMessage > 0 {
+			m.selectedMessage--
+		} else if direction == "down" && m.selectedMessage < len(m.conversationHistory)-1 {
+			m.selectedMessage++
+		}
+		m.updateViewport()
+	}
+	m.followTableCursor()
+}
+
+// jump moves to the first (top) or last item of the current table or list,
+// or the top or bottom of the current viewport.
+func (m *model) jump(top bool) {
+	if t, _, _ := m.activeTable(); t != nil {
+		if top {
+			t.GotoTop()
+		} else {
+			t.GotoBottom()
+		}
+		m.followTableCursor()
+		return
+	}
+
+	switch m.viewMode {
+	case ChatView:
+		if top {
+			m.viewport.GotoTop()
+		} else {
+			m.viewport.GotoBottom()
+		}
+	case PromptPreviewView, ModelDetailView, AgentChainView:
+		if top {
+			m.previewViewport.GotoTop()
+		} else {
+			m.previewViewport.GotoBottom()
+		}
+	case MessageSelectView:
+		if top {
+			m.selectedMessage = 0
+		} else {
+			m.selectedMessage = len(m.conversationHistory) - 1
+		}
+		m.updateViewport()
+	}
+}
+
+// halfPage scrolls the current viewport by half its height.
+func (m *model) halfPage(direction string) {
+	vp := &m.viewport
+	switch m.viewMode {
+	case ChatView:
+	case PromptPreviewView, ModelDetailView, AgentChainView:
+		vp = &m.previewViewport
+	default:
+		return
+	}
+	if direction == "up" {
+		vp.HalfViewUp()
+	} else {
+		vp.HalfViewDown()
 	}
 }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
-	if m.viewMode == ChatListView {
-		return m.updateChatList(msg)
+	// tables are only sized while visible, so a newly shown one is fitted
+	// to the current window
+	previousView := m.viewMode
+	defer func() {
+		m.showQueuedCommandConfirm()
+		if m.viewMode != previousView {
+			m.resizeActiveTable()
+		}
+	}()
+
+	// handled before anything else so the agent chain waiting on the reply
+	// never blocks behind another view or the error screen, and the Ollama
+	// health check and toasts keep running
+	switch msg := msg.(type) {
+	case commandConfirmMsg:
+		// waits for whatever the user is answering or filling in, and is
+		// shown once that closes
+		m.queuedCommands = append(m.queuedCommands, msg)
+		return m, nil
+	case ollamaStatusMsg:
+		m.ollamaRunning = bool(msg)
+		m.updateTextareaIndicatorColor()
+		return m, nil
+	case ollamaHealthTickMsg:
+		next := scheduleOllamaHealthCheck(m.healthCheckInterval())
+		// a generating chat already shows whether Ollama answers, so don't
+		// add requests to the server while it's busy
+		if m.loading {
+			return m, next
+		}
+		return m, tea.Batch(ollamaHealthCmd(), next)
+	case ollamaHealthMsg:
+		if bool(msg) == m.ollamaRunning {
+			return m, nil
+		}
+		m.ollamaRunning = bool(msg)
+		m.updateTextareaIndicatorColor()
+		if m.ollamaRunning {
+			return m, tea.Batch(m.refreshModels(), m.showToast("Ollama is responding again."))
+		}
+		return m, m.showToast("Lost connection to Ollama.")
+	case ollamaRestartedMsg:
+		m.ollamaRunning = true
+		m.updateTextareaIndicatorColor()
+		return m, tea.Batch(m.refreshModels(), m.showToast("Started Ollama and retried."))
+	case notifyMsg:
+		return m, m.showToast(string(msg))
+	case agentsSavedMsg:
+		m.savedAgents = msg.data
+		return m, m.showToast("Agents saved.")
+	case autoSaveMsg:
+		if int(msg) != m.autoSaveID || !m.autoSavePending {
+			return m, nil
+		}
+		if err := m.saveCurrentChat(); err != nil {
+			log.Printf("Failed to auto-save chat: %v", err)
+			return m, m.showToast("Auto-save failed, see the log for details.")
+		}
+		return m, nil
+	case toastExpiredMsg:
+		if int(msg) == m.toastID {
+			m.toast = ""
+		}
+		return m, nil
+	case retryMsg:
+		m.retryStatus = fmt.Sprintf("Ollama request failed, retrying (%d/%d)...", msg.attempt, msg.maxAttempts)
+		return m, nil
+	case chatCompletedMsg:
+		m.retryStatus = ""
+		return m, m.applyChatCompleted(msg)
+	case semanticSearchMsg:
+		m.semanticSearching = false
+		if msg.err != nil {
+			m.errorMessage = msg.err.Error()
+			m.missingModel, _ = missingModelName(msg.err)
+			return m, nil
+		}
+		m.semanticResults = msg.results
+		m.populateSemanticTable()
+		return m, nil
+	case responseContinuedMsg:
+		m.retryStatus = ""
+		return m, m.applyResponseContinued(msg)
+	case errMsg, modelsMsg:
+		m.retryStatus = ""
+	case toolUsageMsg:
+		m.toolUsages = append(m.toolUsages, ToolUsage(msg))
+		m.populateToolUsageTable()
+		if err := saveToolUsages(m); err != nil {
+			log.Printf("Failed to save tool usages: %v", err)
+		}
+		return m, nil
 	}
 
 	if m.errorMessage != "" {
@@ -246,16 +470,71 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "esc", "q":
 				m.errorMessage = ""
+				m.missingModel = ""
 				return m, nil
 			case "r":
 				m.errorMessage = ""
-				return m, fetchModelsCmd()
+				m.missingModel = ""
+				return m, tea.Batch(m.refreshModels(), m.showToast("Retrying..."))
+			case "s":
+				if m.ollamaUnreachable {
+					m.errorMessage = ""
+					m.ollamaUnreachable = false
+					return m, tea.Batch(startOllamaAndRetryCmd(), m.showToast("Starting Ollama..."))
+				}
+			case "d":
+				if m.missingModel != "" {
+					name := m.missingModel
+					m.errorMessage = ""
+					m.missingModel = ""
+					return m, m.startDownload(name)
+				}
 			}
+			// any other key dismisses the error, except ctrl+z which still quits
+			if !keyIsCtrlZ(msg) {
+				m.errorMessage = ""
+				m.missingModel = ""
+				return m, nil
+			}
+		case tea.WindowSizeMsg:
+			// keep the layout current behind the error, e.g. one shown at startup
 		default:
 			return m, nil
 		}
 	}
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.searchActive && !keyIsCtrlZ(keyMsg) {
+		return m.updateSearch(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.availableFilterActive && !keyIsCtrlZ(keyMsg) {
+		return m.updateAvailableFilter(keyMsg)
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		if m.showHelp {
+			switch keyMsg.String() {
+			case "?", "esc":
+				m.showHelp = false
+			}
+			return m, nil
+		}
+		if keyMsg.String() == "?" && m.helpAvailable() {
+			m.showHelp = true
+			return m, nil
+		}
+	}
+
+	if mouseMsg, ok := msg.(tea.MouseMsg); ok {
+		if !m.formActive && !m.agentFormActive {
+			m.handleMouse(mouseMsg)
+		}
+		return m, nil
+	}
+
+	if m.viewMode == ChatListView {
+		return m.updateChatList(msg)
+	}
+
 	// global key handling (esc/ctrl+z)
 	switch msg := msg.(type) {
 	case initialTransitionMsg:
@@ -264,12 +543,52 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if keyIsCtrlZ(msg) {
+			if m.hasUnsavedTemporaryChat() && m.confirmDeleteType == "" && !m.formActive {
+				m.confirmDiscardTemporaryChat("quit")
+				return m, nil
+			}
+			m.stashDraft()
+			m.flushAutoSave()
 			return m, tea.Quit
 		}
 
+		if m.viewMode == DownloadingView && (msg.String() == "esc" || msg.String() == "ctrl+c") {
+			return m, m.cancelDownload()
+		}
+
 		if msg.String() == "esc" {
+			if m.formActive && m.viewMode == ProjectFormView {
+				m.formActive = false
+				m.editingProject = ""
+				m.viewMode = ChatListView
+				return m, triggerWindowResize(m.width, m.height)
+			}
+			if m.formActive && m.viewMode == SemanticSearchFormView {
+				m.formActive = false
+				m.viewMode = ChatListView
+				return m, triggerWindowResize(m.width, m.height)
+			}
+			if m.formActive && m.viewMode == RenameChatFormView {
+				m.formActive = false
+				m.chatToRename = ""
+				m.viewMode = ChatListView
+				return m, triggerWindowResize(m.width, m.height)
+			}
+			if m.formActive && (m.viewMode == PullModelFormView || m.viewMode == CreateModelFormView) {
+				m.formActive = false
+				m.viewMode = ModelView
+				m.modelTable.Focus()
+				return m, nil
+			}
+			if m.formActive && (m.viewMode == AgentExportFormView || m.viewMode == AgentImportFormView || m.viewMode == AgentToolsFormView) {
+				m.formActive = false
+				m.viewMode = AgentView
+				m.agentsTable.Focus()
+				return m, nil
+			}
 			if m.formActive {
 				m.formActive = false
+				m.discardAction = ""
 				m.viewMode = ChatView
 				m.textarea.Focus()
 				return m, nil
@@ -280,43 +599,115 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.agentsTable.Focus()
 				return m, nil
 			}
+			if m.confirmForm != nil && m.confirmDeleteType == "command" {
+				m.resolveCommandConfirm(false)
+				return m, nil
+			}
 			if m.confirmForm != nil {
 				m.viewMode = (func() viewMode {
-					if m.confirmDeleteType == "model" {
+					switch m.confirmDeleteType {
+					case "model":
 						return ModelView
+					case "chat", "chats":
+						return ChatListView
+					case "regenerate", "discard", "clear", "agents":
+						return ChatView
+					case "ollama":
+						return m.viewBeforeConfirm
 					}
 					return AgentView
 				})()
 				m.confirmDeleteModelName = ""
 				m.agentToDelete = ""
+				m.chatToDelete = ""
+				m.discardAction = ""
 				m.confirmDeleteType = ""
 				m.confirmForm = nil
 
 				switch m.viewMode {
 				case ModelView:
 					m.modelTable.Focus()
-					return m, fetchModelsCmd()
+					return m, m.refreshModels()
 				case AgentView:
 					m.agentsTable.Focus()
+				case ChatListView:
+					return m, triggerWindowResize(m.width, m.height)
 				}
 				return m, nil
 			}
+			if m.editingMessage >= 0 {
+				m.editingMessage = -1
+				m.textarea.Reset()
+			}
+			if m.viewMode == ChatView && m.searchQuery != "" {
+				m.clearSearch()
+				return m, nil
+			}
+			if m.viewMode == ModelDetailView {
+				m.viewMode = ModelView
+				m.modelTable.Focus()
+				return m, nil
+			}
+			if m.viewMode == AgentChainView {
+				m.viewMode = AgentView
+				m.agentsTable.Focus()
+				return m, nil
+			}
+			if m.viewMode == SemanticSearchView {
+				m.semanticTable.Blur()
+				m.viewMode = ChatListView
+				return m, triggerWindowResize(m.width, m.height)
+			}
+			rerender := m.viewMode == MessageSelectView
 			m.viewMode = ChatView
 			m.formActive = false
 			m.agentFormActive = false
 			m.textarea.Focus()
+			if rerender {
+				m.updateViewport()
+			}
 			return m, nil
 		}
 	}
 
+	if m.viewMode == FilePickerView {
+		if _, ok := msg.(tea.WindowSizeMsg); !ok {
+			return m.updateFilePicker(msg)
+		}
+	}
+
 	if m.formActive {
 		var updatedForm interface{}
 		var formCmd tea.Cmd
 
 		switch m.viewMode {
-		case NewChatFormView:
+		case NewChatFormView, RenameChatFormView, SaveChatFormView:
 			updatedForm, formCmd = m.newChatForm.Update(msg)
 			m.newChatForm = updatedForm.(*huh.Form)
+		case ExportFormView:
+			updatedForm, formCmd = m.exportForm.Update(msg)
+			m.exportForm = updatedForm.(*huh.Form)
+		case AgentExportFormView, AgentImportFormView:
+			updatedForm, formCmd = m.agentTransferForm.Update(msg)
+			m.agentTransferForm = updatedForm.(*huh.Form)
+		case PullModelFormView:
+			updatedForm, formCmd = m.pullModelForm.Update(msg)
+			m.pullModelForm = updatedForm.(*huh.Form)
+		case ModelSwitchFormView:
+			updatedForm, formCmd = m.modelSwitchForm.Update(msg)
+			m.modelSwitchForm = updatedForm.(*huh.Form)
+		case AgentToolsFormView:
+			updatedForm, formCmd = m.agentToolsForm.Update(msg)
+			m.agentToolsForm = updatedForm.(*huh.Form)
+		case SemanticSearchFormView:
+			updatedForm, formCmd = m.semanticForm.Update(msg)
+			m.semanticForm = updatedForm.(*huh.Form)
+		case ProjectFormView:
+			updatedForm, formCmd = m.projectForm.Update(msg)
+			m.projectForm = updatedForm.(*huh.Form)
+		case CreateModelFormView:
+			updatedForm, formCmd = m.createModelForm.Update(msg)
+			m.createModelForm = updatedForm.(*huh.Form)
 		case AgentFormView:
 			updatedForm, formCmd = m.agentForm.Update(msg)
 			m.agentForm = updatedForm.(*huh.Form)
@@ -329,14 +720,12 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case NewChatFormView:
 			if m.newChatForm.State == huh.StateCompleted {
 				if m.newChatName == "" {
-					m.errorMessage = "Chat name cannot be empty"
 					m.newChatForm.State = huh.StateNormal
-					return m, nil
+					return m, m.showToast("Chat name cannot be empty")
 				}
 				if m.newProjectName == "" {
-					m.errorMessage = "Project name cannot be empty"
 					m.newChatForm.State = huh.StateNormal
-					return m, nil
+					return m, m.showToast("Project name cannot be empty")
 				}
 
 				err := m.createNewChat(m.newChatName, m.newProjectName)
@@ -354,6 +743,129 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.updateViewport()
 				return m, nil
 			}
+		case RenameChatFormView:
+			if m.newChatForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = ChatListView
+				if err := m.renameChat(m.chatToRename, m.newChatName, m.newProjectName); err != nil {
+					m.errorMessage = fmt.Sprintf("Failed to rename chat: %v", err)
+				}
+				m.chatToRename = ""
+				m.newChatName = ""
+				m.newProjectName = ""
+				return m, triggerWindowResize(m.width, m.height)
+			}
+		case ChatView:
+			if m.configForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.textarea.Focus()
+				if err := saveConfig(m); err != nil {
+					m.errorMessage = fmt.Sprintf("Failed to save config: %v", err)
+				}
+				m.updateViewport()
+				return m, nil
+			}
+		case SaveChatFormView:
+			if m.newChatForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = ChatView
+				m.textarea.Focus()
+				if err := m.promoteTemporaryChat(m.newChatName, m.newProjectName); err != nil {
+					m.discardAction = ""
+					m.errorMessage = fmt.Sprintf("Failed to save chat: %v", err)
+					return m, nil
+				}
+				name := m.newChatName
+				m.newChatName = ""
+				m.newProjectName = ""
+				if m.discardAction != "" {
+					return m, m.completeDiscardAction()
+				}
+				return m, func() tea.Msg { return notifyMsg(fmt.Sprintf("Saved chat '%s'.", name)) }
+			}
+		case ExportFormView:
+			if m.exportForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = ChatView
+				m.textarea.Focus()
+
+				chat := newTemporaryChat()
+				if m.selectedChat != nil {
+					chat = *m.selectedChat
+				}
+				path := strings.TrimSpace(m.exportFileName)
+				if filepath.Ext(path) == "" {
+					path += ".md"
+				}
+				return m, exportChatCmd(chat, m.conversationHistory, path)
+			}
+		case PullModelFormView:
+			if m.pullModelForm.State == huh.StateCompleted {
+				m.formActive = false
+				return m, m.startDownload(strings.TrimSpace(m.pullModelName))
+			}
+		case SemanticSearchFormView:
+			if m.semanticForm.State == huh.StateCompleted {
+				m.formActive = false
+				return m, m.startSemanticSearch()
+			}
+		case ModelSwitchFormView:
+			if m.modelSwitchForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = ChatView
+				m.textarea.Focus()
+				return m, m.applyModelSwitch()
+			}
+		case AgentToolsFormView:
+			if m.agentToolsForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = AgentView
+				m.agentsTable.Focus()
+				return m, m.applyAgentTools()
+			}
+		case ProjectFormView:
+			if m.projectForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = ChatListView
+				if err := m.saveProjectSettings(); err != nil {
+					m.errorMessage = fmt.Sprintf("Failed to save project settings: %v", err)
+					return m, nil
+				}
+				return m, tea.Batch(triggerWindowResize(m.width, m.height), m.showToast("Project settings saved."))
+			}
+		case CreateModelFormView:
+			if m.createModelForm.State == huh.StateCompleted {
+				m.formActive = false
+				return m, m.startCreate(strings.TrimSpace(m.createModelName), strings.TrimSpace(m.modelfilePath))
+			}
+		case AgentExportFormView:
+			if m.agentTransferForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = AgentView
+				m.agentsTable.Focus()
+
+				agents := m.agents
+				if m.agentTransferOption == "selected" {
+					agent, ok := m.hoveredAgent()
+					if !ok {
+						return m, func() tea.Msg { return notifyMsg("No agent selected to export.") }
+					}
+					agents = []Agent{agent}
+				}
+				return m, exportAgentsCmd(agents, strings.TrimSpace(m.agentTransferPath))
+			}
+		case AgentImportFormView:
+			if m.agentTransferForm.State == huh.StateCompleted {
+				m.formActive = false
+				m.viewMode = AgentView
+				m.agentsTable.Focus()
+
+				summary, err := m.importAgents(strings.TrimSpace(m.agentTransferPath), m.agentTransferOption)
+				if err != nil {
+					return m, func() tea.Msg { return errMsg(fmt.Errorf("failed to import agents: %w", err)) }
+				}
+				return m, func() tea.Msg { return notifyMsg(summary) }
+			}
 		}
 		return m, formCmd
 	}
@@ -364,16 +876,9 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch m.agentForm.State {
 		case huh.StateCompleted:
-			m.currentEditingAgent.Tools = []Tool{}
-
-			for _, toolName := range m.currentEditingAgent.SelectedTools {
-				for _, availableTool := range m.availableTools {
-					if availableTool.Name == toolName {
-						m.currentEditingAgent.Tools = append(m.currentEditingAgent.Tools, availableTool)
-						break
-					}
-				}
-			}
+			m.currentEditingAgent.StopSequences = parseStopSequences(m.currentEditingAgent.stopInput)
+			m.currentEditingAgent.stopInput = ""
+			m.currentEditingAgent.Tools = m.selectedTools(m.currentEditingAgent.SelectedTools)
 
 			if m.agentAction == "add" {
 				m.agents = append(m.agents, m.currentEditingAgent)
@@ -381,6 +886,8 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else if m.agentAction == "edit" {
 				for i, agent := range m.agents {
 					if strings.EqualFold(agent.Role, m.selectedAgent.Role) {
+						// the saved model replaces a quick switch from the chat
+						delete(m.modelOverrides, agent.Role)
 						m.agents[i] = m.currentEditingAgent
 						log.Printf("Edited agent with role: %s\n", m.currentEditingAgent.Role)
 						break
@@ -483,6 +990,84 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.agentsTable.Focus()
 					return m, nil
 				}
+			} else if m.confirmDeleteType == "ollama" {
+				m.viewMode = m.viewBeforeConfirm
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				if m.confirmResult {
+					return m, stopOllamaCmd()
+				}
+				return m, nil
+			} else if m.confirmDeleteType == "agents" {
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				if m.confirmResult {
+					m.viewMode = AgentView
+					m.agentsTable.Focus()
+					return m, m.refreshModels()
+				}
+				m.viewMode = ChatView
+				return m, nil
+			} else if m.confirmDeleteType == "reload" {
+				m.viewMode = AgentView
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				m.agentsTable.Focus()
+				if m.confirmResult {
+					return m, m.reloadAgents()
+				}
+				return m, nil
+			} else if m.confirmDeleteType == "command" {
+				m.resolveCommandConfirm(m.confirmResult)
+				return m, nil
+			} else if m.confirmDeleteType == "clear" {
+				m.viewMode = ChatView
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				if m.confirmResult {
+					return m, m.clearConversation()
+				}
+				return m, nil
+			} else if m.confirmDeleteType == "regenerate" {
+				m.viewMode = ChatView
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				if m.confirmResult {
+					return m, m.regenerateFrom(m.editedMessage)
+				}
+				return m, nil
+			} else if m.confirmDeleteType == "discard" {
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				if m.confirmResult {
+					m.newChatName = ""
+					m.newProjectName = ""
+					m.newChatForm = createNewChatForm(&m.newChatName, &m.newProjectName)
+					m.viewMode = SaveChatFormView
+					m.formActive = true
+					return m, nil
+				}
+				m.viewMode = ChatView
+				return m, m.completeDiscardAction()
+			} else if m.confirmDeleteType == "chats" {
+				m.viewMode = ChatListView
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				if m.confirmResult {
+					return m, tea.Batch(m.deleteMarkedChats(), triggerWindowResize(m.width, m.height))
+				}
+				return m, triggerWindowResize(m.width, m.height)
+			} else if m.confirmDeleteType == "chat" {
+				m.viewMode = ChatListView
+				if m.confirmResult {
+					if err := m.deleteChat(m.chatToDelete); err != nil {
+						m.errorMessage = fmt.Sprintf("Failed to delete chat: %v", err)
+					}
+				}
+				m.chatToDelete = ""
+				m.confirmDeleteType = ""
+				m.confirmForm = nil
+				return m, triggerWindowResize(m.width, m.height)
 			}
 
 			m.confirmDeleteModelName = ""
@@ -495,13 +1080,11 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
-	case notifyMsg:
-		m.errorMessage = string(msg)
-		return m, nil
-
 	case tea.KeyMsg:
 		switch {
 		case keyIsCtrlZ(msg):
+			m.stashDraft()
+			m.flushAutoSave()
 			return m, tea.Quit
 		}
 
@@ -509,21 +1092,210 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleEnterKey()
 		}
 
+		if m.loading && blockedWhileGenerating(m.viewMode, msg.String()) {
+			return m, m.busyToast()
+		}
+
 		if m.viewMode == InsertView {
 			m.textarea, cmd = m.textarea.Update(msg)
 			return m, cmd
 		}
 
 		switch msg.String() {
+		case "/":
+			if m.viewMode == ChatView {
+				return m, m.startSearch()
+			}
+			if m.viewMode == AvailableModelsView {
+				return m, m.startAvailableFilter()
+			}
+		case "n":
+			if m.viewMode == ChatView {
+				m.jumpToMatch(1)
+				return m, nil
+			}
+			if m.viewMode == ModelView {
+				m.createModelName = ""
+				m.modelfilePath = ""
+				m.createModelForm = createModelfileForm(&m.createModelName, &m.modelfilePath)
+				m.viewMode = CreateModelFormView
+				m.formActive = true
+				m.modelTable.Blur()
+				return m, nil
+			}
+		case "N":
+			if m.viewMode == ChatView {
+				m.jumpToMatch(-1)
+				return m, nil
+			}
+		case "p":
+			if m.viewMode == ChatView {
+				return m, m.showPromptPreview()
+			}
+			if m.viewMode == ModelView {
+				m.pullModelName = ""
+				m.pullModelForm = createPullModelForm(&m.pullModelName)
+				m.viewMode = PullModelFormView
+				m.formActive = true
+				m.modelTable.Blur()
+				return m, nil
+			}
+		case "w":
+			if m.viewMode == ChatView {
+				return m, m.revealChatFile()
+			}
+		case "ctrl+l":
+			if m.viewMode == ChatView && len(m.conversationHistory) > 0 {
+				m.confirmDeleteType = "clear"
+				m.confirmForm = createConfirmForm("Clear every message in this chat? This can't be undone.", &m.confirmResult)
+				m.viewMode = ConfirmDelete
+				m.textarea.Blur()
+				return m, nil
+			}
 		case "o":
 			if m.viewMode == ChatView || m.viewMode == ModelView {
 				return m, m.toggleOllamaServe()
 			}
 			return m, nil
+		case "c":
+			if m.viewMode == AgentView {
+				agent, ok := m.hoveredAgent()
+				if !ok {
+					return m, nil
+				}
+				m.agentAction = "add"
+				m.currentEditingAgent = cloneAgent(agent, m.agentRolesExcept(""))
+				m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools, m.agentRolesExcept(""))
+				m.agentFormActive = true
+				m.viewMode = AgentFormView
+				m.agentsTable.Blur()
+				return m, nil
+			}
+			if m.viewMode == ChatView {
+				m.configForm = createConfigForm(&m.config, m.availableModelVersions)
+				m.formActive = true
+				m.textarea.Blur()
+				return m, nil
+			}
+		case "s":
+			if m.viewMode == ChatView {
+				if !m.isTemporaryChat() {
+					return m, func() tea.Msg { return notifyMsg("This chat is already saved.") }
+				}
+				m.newChatName = ""
+				m.newProjectName = ""
+				m.newChatForm = createNewChatForm(&m.newChatName, &m.newProjectName)
+				m.viewMode = SaveChatFormView
+				m.formActive = true
+				m.textarea.Blur()
+				return m, nil
+			}
+		case "t":
+			if m.viewMode == AgentView {
+				return m, m.openAgentTools()
+			}
+			if m.viewMode == ChatView {
+				m.viewMode = ToolUsageView
+				m.populateToolUsageTable()
+				m.toolUsageTable.Focus()
+				m.textarea.Blur()
+				return m, nil
+			}
+		case "v":
+			if m.viewMode == ChatView && len(m.conversationHistory) > 0 {
+				m.viewMode = MessageSelectView
+				m.selectedMessage = len(m.conversationHistory) - 1
+				m.textarea.Blur()
+				m.updateViewport()
+				return m, nil
+			}
+			if m.viewMode == ModelView {
+				return m, m.openModelDetails()
+			}
+			if m.viewMode == AgentView {
+				m.showAgentChain()
+				return m, nil
+			}
+		case "r":
+			if m.viewMode == ChatView {
+				m.toggleRawOutput()
+				return m, nil
+			}
+			if m.viewMode == AvailableModelsView {
+				return m, fetchAvailableModelsCmd(m.libraryCachePath, m.libraryCacheTTL(), true)
+			}
+			if m.viewMode == AgentView {
+				if m.hasUnsavedAgentChanges() {
+					m.confirmDeleteType = "reload"
+					m.confirmForm = createConfirmForm("Your agent changes haven't been saved. Reload agents from disk and discard them?", &m.confirmResult)
+					m.viewMode = ConfirmDelete
+					m.agentsTable.Blur()
+					return m, nil
+				}
+				return m, m.reloadAgents()
+			}
+		case "pgdown":
+			if m.viewMode == AvailableModelsView {
+				m.availablePage++
+				m.showAvailablePage()
+				return m, nil
+			}
+		case "pgup":
+			if m.viewMode == AvailableModelsView {
+				m.availablePage--
+				m.showAvailablePage()
+				return m, nil
+			}
+		case "T":
+			if m.viewMode == ChatView {
+				theme := nextTheme(activeTheme.Name)
+				m.applyTheme(theme)
+				m.config.Theme = theme.Name
+				if err := saveConfig(m); err != nil {
+					log.Printf("Failed to save config: %v", err)
+				}
+				m.updateViewport()
+				return m, nil
+			}
+		case "R":
+			if m.viewMode == ChatView {
+				return m, m.regenerateLastResponse()
+			}
+		case "C":
+			if m.viewMode == ChatView {
+				return m, m.continueLastResponse()
+			}
+		case "x":
+			if m.viewMode == AgentView {
+				m.agentTransferPath = ""
+				m.agentTransferOption = "all"
+				m.agentTransferForm = createAgentExportForm(&m.agentTransferPath, &m.agentTransferOption)
+				m.viewMode = AgentExportFormView
+				m.formActive = true
+				m.agentsTable.Blur()
+				return m, nil
+			}
+			if m.viewMode == ChatView {
+				if len(m.conversationHistory) == 0 {
+					return m, func() tea.Msg { return notifyMsg("Nothing to export yet.") }
+				}
+				if m.isTemporaryChat() {
+					m.exportFileName = ""
+					m.exportForm = createExportForm(&m.exportFileName)
+					m.viewMode = ExportFormView
+					m.formActive = true
+					m.textarea.Blur()
+					return m, nil
+				}
+				return m, exportChatCmd(*m.selectedChat, m.conversationHistory, exportFileName(*m.selectedChat))
+			}
 		case "f":
 			if m.viewMode == ChatView || m.viewMode == InsertView {
-				m.viewMode = FilePickerView
-				return m, nil
+				return m, m.openFilePicker(false)
+			}
+		case "M":
+			if m.viewMode == ChatView {
+				return m, m.openModelSwitch()
 			}
 		case "m":
 			if m.viewMode == ChatView {
@@ -533,11 +1305,23 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.availableTable.Blur()
 				m.agentsTable.Blur()
 				m.parameterSizesTable.Blur()
-				return m, fetchModelsCmd()
+				return m, m.refreshModels()
 			}
 			return m, nil
 		case "i":
+			if m.viewMode == AgentView {
+				m.agentTransferPath = ""
+				m.agentTransferOption = "skip"
+				m.agentTransferForm = createAgentImportForm(&m.agentTransferPath, &m.agentTransferOption)
+				m.viewMode = AgentImportFormView
+				m.formActive = true
+				m.agentsTable.Blur()
+				return m, nil
+			}
 			if m.viewMode == ChatView {
+				if m.confirmNoAgents() {
+					return m, nil
+				}
 				m.viewMode = InsertView
 				m.textarea.Focus()
 				m.modelTable.Blur()
@@ -553,24 +1337,38 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.modelTable.Blur()
 				m.availableTable.Blur()
 				m.parameterSizesTable.Blur()
-				return m, fetchModelsCmd()
+				return m, m.refreshModels()
 			}
 		case "l":
 			if m.viewMode == ChatView {
+				if m.hasUnsavedTemporaryChat() {
+					m.confirmDiscardTemporaryChat("list")
+					return m, nil
+				}
 				m.viewMode = ChatListView
 				return m, triggerWindowResize(m.width, m.height)
 			}
 		case "a":
+			if m.viewMode == ChatView {
+				return m, m.openFilePicker(true)
+			}
 			if m.viewMode == AgentView {
 				m.agentAction = "add"
-				m.currentEditingAgent = Agent{}
-				m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools)
+				m.currentEditingAgent = Agent{ModelVersion: m.config.ModelVersion, Enabled: true, UseChainInput: true}
+				m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools, m.agentRolesExcept(""))
 				m.agentFormActive = true
 				m.viewMode = AgentFormView
 				m.agentsTable.Blur()
 				return m, nil
 			}
 		case "e":
+			if m.viewMode == MessageSelectView {
+				m.editingMessage = m.selectedMessage
+				m.textarea.SetValue(m.conversationHistory[m.selectedMessage]["content"])
+				m.viewMode = InsertView
+				m.textarea.Focus()
+				return m, nil
+			}
 			if m.viewMode == AgentView {
 				selectedRow := m.agentsTable.SelectedRow()
 				if selectedRow == nil || selectedRow[0] == "Add New Agent" {
@@ -585,12 +1383,16 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 				m.agentAction = "edit"
-				m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools)
+				m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools, m.agentRolesExcept(m.selectedAgent.Role))
+				m.agentFormActive = true
 				m.viewMode = AgentFormView
 				m.agentsTable.Blur()
 				return m, nil
 			}
 		case "d":
+			if m.viewMode == MessageSelectView {
+				return m, m.deleteSelectedMessage()
+			}
 			if m.viewMode == AgentView {
 				selectedRow := m.agentsTable.SelectedRow()
 				if selectedRow == nil || selectedRow[0] == "Add New Agent" {
@@ -618,13 +1420,42 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "u":
 			if m.viewMode == AgentView {
-				m.moveAgentUp()
-				return m, saveAgentsCmd(m)
+				if m.moveAgentUp() {
+					return m, saveAgentsCmd(m)
+				}
+				return m, nil
+			}
+		case " ":
+			if m.viewMode == AgentView {
+				if m.toggleHoveredAgent() {
+					return m, saveAgentsCmd(m)
+				}
+				return m, nil
+			}
+		case "ctrl+u":
+			if m.viewMode == AgentView {
+				if m.undoAgentMove() {
+					return m, saveAgentsCmd(m)
+				}
+				return m, m.showToast("Nothing to undo.")
 			}
+			m.halfPage("up")
 		case "y":
 			if m.viewMode == AgentView {
-				m.moveAgentDown()
-				return m, saveAgentsCmd(m)
+				if m.moveAgentDown() {
+					return m, saveAgentsCmd(m)
+				}
+				return m, nil
+			}
+			if m.viewMode == ChatView {
+				return m, m.copyLastResponse(false)
+			}
+			if m.viewMode == MessageSelectView && m.selectedMessage < len(m.conversationHistory) {
+				return m, copyToClipboardCmd(m.conversationHistory[m.selectedMessage]["content"], "the selected message")
+			}
+		case "Y":
+			if m.viewMode == ChatView {
+				return m, m.copyLastResponse(true)
 			}
 		case "esc":
 			if m.viewMode == FilePickerView {
@@ -651,14 +1482,22 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.navigate("down")
 		case "k", "up":
 			m.navigate("up")
+		case "home":
+			m.jump(true)
+		case "end", "G":
+			m.jump(false)
+		case "ctrl+d":
+			m.halfPage("down")
 		}
 
 	case modelsMsg:
+		m.modelsLoading = false
 		if len(msg) == 0 {
 			log.Println("No models available to populate the model table.")
 			return m, nil
 		}
 
+		m.installedModels = msg
 		m.populateModelTable(msg)
 
 		m.availableModelVersions = make([]string, len(msg))
@@ -666,24 +1505,80 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.availableModelVersions[i] = mdl.Model
 		}
 
+		if notice := m.reconcileDefaultModel(); notice != "" {
+			return m, func() tea.Msg { return notifyMsg(notice) }
+		}
 		return m, nil
 
 	case availableModelsMsg:
-		m.availableModels = msg
-		m.populateAvailableModelsTable(msg)
+		m.availableModels = msg.models
+		m.availableModelsCached = msg.cached
+		m.availableModelsFetched = msg.fetchedAt
+		m.applyAvailableFilter()
+
+	case modelDetailsMsg:
+		if m.viewMode != ModelView {
+			return m, nil
+		}
+		if msg.err != nil {
+			return m, func() tea.Msg {
+				return errMsg(fmt.Errorf("failed to load details for %s: %w", msg.model.Name, msg.err))
+			}
+		}
+		m.showModelDetails(msg.model, msg.info)
+		return m, nil
+
+	case modelTagsMsg:
+		m.quantizationsLoading = false
+		if m.viewMode != ParameterSizesView {
+			return m, nil
+		}
+		if msg.err != nil {
+			log.Printf("Failed to look up tags for %s, using the default quantization: %v", m.selectedAvailableModel.Name, msg.err)
+		}
+
+		fullModelName := fmt.Sprintf("%s:%s", m.selectedAvailableModel.Name, m.selectedModelSize)
+		m.quantizationTags = quantizationTags(msg.tags, m.selectedModelSize)
+		if len(m.quantizationTags) == 0 {
+			m.parameterSizesTable.Blur()
+			return m, m.startDownload(fullModelName)
+		}
+
+		m.populateQuantizationTable()
+		m.viewMode = QuantizationView
+		m.quantizationTable.Focus()
+		m.parameterSizesTable.Blur()
+		return m, nil
 
 	case modelDeletedMsg:
 		m.viewMode = ModelView
 		m.modelTable.Focus()
-		return m, fetchModelsCmd()
+		return m, m.refreshModels()
+
+	case pullProgressMsg:
+		m.pullStatus = msg.Status
+		m.pullTotal = msg.Total
+		m.pullCompleted = msg.Completed
+
+		var progressCmd tea.Cmd
+		if msg.Total > 0 {
+			progressCmd = m.downloadProgress.SetPercent(float64(msg.Completed) / float64(msg.Total))
+		}
+		return m, tea.Batch(progressCmd, waitForPullProgress(msg.ch))
+
+	case progress.FrameMsg:
+		progressModel, progressCmd := m.downloadProgress.Update(msg)
+		m.downloadProgress = progressModel.(progress.Model)
+		return m, progressCmd
 
 	case modelDownloadedMsg:
+		m.cancelPull = nil
 		m.viewMode = ModelView
 		m.modelTable.Focus()
 		m.availableTable.Blur()
 		m.agentsTable.Blur()
 		m.parameterSizesTable.Blur()
-		return m, fetchModelsCmd()
+		return m, m.refreshModels()
 
 	case agentsMsg:
 		m.agents = msg
@@ -713,75 +1608,47 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case errMsg:
 		m.loading = false
+		m.modelsLoading = false
 		m.errorMessage = msg.Error()
+		m.missingModel, _ = missingModelName(msg)
+		m.ollamaUnreachable = isConnectionRefused(msg)
+		m.updateViewport()
 		return m, nil
 
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 		m.textarea.SetWidth(m.width)
 		m.viewport.Width = m.width
-		m.viewport.Height = m.height - 3
+		m.viewport.Height = m.height - 5
+		m.previewViewport.Width = m.width
+		m.previewViewport.Height = m.height - 2
 		m.updateViewport()
 
-		m.availableTable.SetWidth(m.width)
-		m.availableTable.SetHeight(m.height - 4)
-		m.parameterSizesTable.SetWidth(m.width)
-		m.parameterSizesTable.SetHeight(m.height - 4)
-		m.agentsTable.SetWidth(m.width)
+		m.fitTableColumns()
+		m.resizeActiveTable()
+		if m.viewMode == AvailableModelsView {
+			m.showAvailablePage()
+		}
+		m.downloadProgress.Width = m.width - 4
+		if m.viewMode == AgentChainView {
+			m.previewViewport.SetContent(agentChainDiagram(m.agents, m.width))
+		}
 
 		if m.viewMode == ChatListView {
 			headerHeight := 2
 			m.chatList.SetSize(msg.Width-2, msg.Height-headerHeight)
-		}
-
-		// WIP: file picker for mm inputs
-		if m.viewMode == FilePickerView {
-			var fpCmd tea.Cmd
-			m.filePicker, fpCmd = m.filePicker.Update(msg)
-
-			if didSelect, path := m.filePicker.DidSelectFile(msg); didSelect {
-				base64Image, err := m.loadImageAsBase64(path)
-				if err != nil {
-					m.errorMessage = fmt.Sprintf("Failed to load image: %v", err)
-				} else {
-					m.conversationHistory = append(m.conversationHistory, map[string]string{
-						"role":    "user",
-						"content": fmt.Sprintf("![Selected Image](%s)", base64Image),
-					})
-					m.selectedImage = path
-					m.updateViewport()
-				}
-				m.viewMode = ChatView
-				return m, nil
-			}
-
-			return m, fpCmd
-		}
-
-		switch m.viewMode {
-		case AgentView:
-			availableHeight := m.height - 4
-			if availableHeight < 3 {
-				availableHeight = 3
-			}
-			m.agentsTable.SetHeight(availableHeight)
-		case ChatListView:
 			return m.updateChatList(msg)
-		default:
-			m.agentsTable.SetHeight(m.height - 4)
 		}
 
 		return m, nil
 
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
+		if m.loading && (m.viewMode == ChatView || m.viewMode == InsertView) {
+			m.refreshViewportContent()
+		}
 		return m, cmd
 
-	case responseMsg:
-	case OllamaToggledMsg:
-		m.ollamaRunning = !m.ollamaRunning
-		m.updateTextareaIndicatorColor()
-		return m, nil
 	}
 
 	if m.viewMode == ChatListView {
@@ -816,14 +1683,12 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 	case NewChatFormView:
 		if m.newChatForm.State == huh.StateCompleted {
 			if m.newChatName == "" {
-				m.errorMessage = "Chat name cannot be empty"
 				m.newChatForm.State = huh.StateNormal
-				return m, nil
+				return m, m.showToast("Chat name cannot be empty")
 			}
 			if m.newProjectName == "" {
-				m.errorMessage = "Project name cannot be empty"
 				m.newChatForm.State = huh.StateNormal
-				return m, nil
+				return m, m.showToast("Project name cannot be empty")
 			}
 
 			err := m.createNewChat(m.newChatName, m.newProjectName)
@@ -842,13 +1707,31 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+	case SemanticSearchView:
+		m.openSemanticResult()
+		return m, nil
 	case InsertView:
+		if m.editingMessage >= 0 {
+			return m, m.applyMessageEdit()
+		}
+		if m.loading {
+			return m, m.busyToast()
+		}
 		if !m.formActive && !m.agentFormActive {
+			// keep the typed message rather than lose it to a chain that can't run
+			if m.confirmNoAgents() {
+				m.textarea.Blur()
+				return m, nil
+			}
 			m.currentUserMessage = m.textarea.Value()
 			m.textarea.Reset()
+			if m.selectedChat != nil {
+				delete(m.drafts, m.selectedChat.ID)
+			}
 			m.loading = true
 			m.viewMode = ChatView
 			m.textarea.Blur()
+			m.refreshViewportContent()
 			return m, sendChatMessage(m)
 		}
 	case ModelView:
@@ -861,7 +1744,7 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 			m.viewMode = AvailableModelsView
 			m.availableTable.Focus()
 			m.modelTable.Blur()
-			return m, fetchAvailableModelsCmd()
+			return m, fetchAvailableModelsCmd(m.libraryCachePath, m.libraryCacheTTL(), false)
 		}
 		m.confirmDeleteModelName = modelName
 		m.confirmDeleteType = "model"
@@ -898,13 +1781,24 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 		}
 		size := selectedRow[0]
 		modelName := m.selectedAvailableModel.Name
-		fullModelName := modelName
-		if size != "" {
-			fullModelName = fmt.Sprintf("%s:%s", modelName, size)
+		if size == "" {
+			m.parameterSizesTable.Blur()
+			return m, m.startDownload(modelName)
 		}
-		m.viewMode = DownloadingView
-		m.parameterSizesTable.Blur()
-		return m, tea.Batch(downloadModelCmd(fullModelName), m.spinner.Tick)
+		if m.quantizationsLoading {
+			return m, nil
+		}
+		// look for quantization variants before downloading the default tag
+		m.selectedModelSize = size
+		m.quantizationsLoading = true
+		return m, fetchModelTagsCmd(modelName)
+	case QuantizationView:
+		tag := m.selectedModelSize
+		if cursor := m.quantizationTable.Cursor(); cursor > 0 && cursor <= len(m.quantizationTags) {
+			tag = m.quantizationTags[cursor-1]
+		}
+		m.quantizationTable.Blur()
+		return m, m.startDownload(fmt.Sprintf("%s:%s", m.selectedAvailableModel.Name, tag))
 	case AgentView:
 		selectedRow := m.agentsTable.SelectedRow()
 		if selectedRow == nil {
@@ -913,8 +1807,8 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 		agentRole := selectedRow[0]
 		if agentRole == "Add New Agent" {
 			m.agentAction = "add"
-			m.currentEditingAgent = Agent{}
-			m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools)
+			m.currentEditingAgent = Agent{ModelVersion: m.config.ModelVersion, Enabled: true, UseChainInput: true}
+			m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools, m.agentRolesExcept(""))
 			m.agentFormActive = true
 			m.viewMode = AgentFormView
 			m.agentsTable.Blur()
@@ -929,7 +1823,7 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 			}
 
 			m.agentAction = "edit"
-			m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools)
+			m.agentForm = createAgentForm(&m.currentEditingAgent, m.availableModelVersions, m.availableTools, m.agentRolesExcept(m.selectedAgent.Role))
 			m.agentFormActive = true
 			m.viewMode = AgentFormView
 			m.agentsTable.Blur()
@@ -939,18 +1833,51 @@ func (m *model) handleEnterKey() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m model) View() string {
+func (m model) view() string {
 	if m.errorMessage != "" {
+		if m.missingModel != "" {
+			return fmt.Sprintf(
+				"%s\n\nPress 'd' to download %s, 'r' to retry or esc to continue.",
+				errorStyle.Render(m.errorMessage),
+				m.missingModel,
+			)
+		}
+		if m.ollamaUnreachable {
+			return fmt.Sprintf(
+				"%s\n\nOllama doesn't seem to be running. Press 's' to start it and retry, 'r' to retry or any other key to continue.",
+				errorStyle.Render(m.errorMessage),
+			)
+		}
 		return fmt.Sprintf(
 			"%s\n\nPress 'r' to retry or any other key to continue.",
 			errorStyle.Render(m.errorMessage),
 		)
 	}
 
+	if m.showHelp {
+		return m.helpView()
+	}
+
 	if m.formActive {
 		switch m.viewMode {
-		case NewChatFormView:
+		case NewChatFormView, RenameChatFormView, SaveChatFormView:
 			return m.newChatForm.View()
+		case ExportFormView:
+			return m.exportForm.View()
+		case AgentExportFormView, AgentImportFormView:
+			return m.agentTransferForm.View()
+		case PullModelFormView:
+			return m.pullModelForm.View()
+		case ModelSwitchFormView:
+			return m.modelSwitchForm.View()
+		case AgentToolsFormView:
+			return m.agentToolsForm.View()
+		case SemanticSearchFormView:
+			return m.semanticForm.View()
+		case CreateModelFormView:
+			return m.createModelForm.View()
+		case ProjectFormView:
+			return m.projectForm.View()
 		case AgentFormView:
 			return m.agentForm.View()
 		default:
@@ -968,17 +1895,20 @@ func (m model) View() string {
 
 	switch m.viewMode {
 	case FilePickerView:
+		if m.pickingTextFile {
+			return fmt.Sprintf(
+				"Select a text file to send as context with your next message (%d attached):\n\n%s\n\n(press esc to cancel)",
+				len(m.pendingFiles),
+				m.filePicker.View(),
+			)
+		}
 		return fmt.Sprintf(
-			"Select an image file:\n\n%s\n\n(press esc to cancel)",
+			"Select an image file (%d attached):\n\n%s\n\n(press esc to cancel)",
+			len(m.pendingImages),
 			m.filePicker.View(),
 		)
 	case ChatListView:
-		header := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Background(lipgloss.Color("#666666")).
-			Padding(0, 1).
-			MarginBottom(1).
-			Render("Chat List (Enter to select, / to search, ESC to go back)")
+		header := m.chatListHeader()
 
 		return fmt.Sprintf("%s\n%s", header, m.chatList.View())
 
@@ -993,6 +1923,12 @@ func (m model) View() string {
 		} else {
 			status = "Ollama Serve: Stopped"
 		}
+		if m.retryStatus != "" {
+			status += " | " + m.retryStatus
+		}
+		if m.modelsLoading {
+			status += fmt.Sprintf(" | %s Loading models...", m.spinner.View())
+		}
 		indicator := m.indicatorStyle().Render(status)
 
 		return indicator + "\n" + m.modelTable.View()
@@ -1002,16 +1938,109 @@ func (m model) View() string {
 	case AgentFormView:
 		return m.agentFormView()
 	case AvailableModelsView:
-		return "Available Ollama Models:\n\n" + m.availableTable.View()
+		return m.availableModelsView()
+	case ToolUsageView:
+		return m.toolUsageView()
+	case SemanticSearchView:
+		return m.semanticSearchView()
+	case PromptPreviewView:
+		return m.promptPreviewView()
+	case ModelDetailView:
+		return m.modelDetailView()
+	case AgentChainView:
+		return m.agentChainView()
 	case ParameterSizesView:
-		return fmt.Sprintf("Select Parameter Size for '%s':\n\n%s", m.selectedAvailableModel.Name, m.parameterSizesTable.View())
+		loading := ""
+		if m.quantizationsLoading {
+			loading = fmt.Sprintf("  %s Looking up quantizations...", m.spinner.View())
+		}
+		return fmt.Sprintf("Select Parameter Size for '%s':%s\n\n%s", m.selectedAvailableModel.Name, loading, m.parameterSizesTable.View())
+	case QuantizationView:
+		return fmt.Sprintf("Select Quantization for '%s:%s':\n\n%s", m.selectedAvailableModel.Name, m.selectedModelSize, m.quantizationTable.View())
 	case DownloadingView:
-		return fmt.Sprintf("%s Downloading model, feel free to exit this page", m.spinner.View())
+		return m.downloadingView()
 	case InsertView:
-		return m.viewport.View() + "\n" + m.textarea.View()
+		return m.viewport.View() + "\n" + m.tokenStatusLine() + "\n" + m.textarea.View() + "\n" + m.statusBar()
+	case MessageSelectView:
+		return fmt.Sprintf(
+			"%s\nMessage %d/%d — 'e' to edit, 'd' to delete, 'y' to copy, esc to go back\n%s",
+			m.viewport.View(), m.selectedMessage+1, len(m.conversationHistory), m.statusBar(),
+		)
 	default:
-		return m.viewport.View() + "\n" + m.textarea.View()
+		if m.retryStatus != "" {
+			return m.viewport.View() + "\n" + errorStyle.Render(m.retryStatus) + "\n" + m.textarea.View() + "\n" + m.statusBar()
+		}
+		if m.searchActive || m.searchQuery != "" {
+			return m.viewport.View() + "\n" + m.searchStatusLine() + "\n" + m.textarea.View() + "\n" + m.statusBar()
+		}
+		return m.viewport.View() + "\n" + m.tokenStatusLine() + "\n" + m.textarea.View() + "\n" + m.statusBar()
+	}
+}
+
+// showQueuedCommandConfirm asks about the next command an agent wants to run,
+// once no other confirm or form is open.
+func (m *model) showQueuedCommandConfirm() {
+	if len(m.queuedCommands) == 0 || m.confirmForm != nil || m.formActive || m.agentFormActive {
+		return
+	}
+	msg := m.queuedCommands[0]
+	m.queuedCommands = m.queuedCommands[1:]
+
+	m.pendingCommand = &msg
+	m.viewBeforeConfirm = m.viewMode
+	m.confirmDeleteType = "command"
+	m.confirmResult = false
+	m.confirmForm = createConfirmForm(fmt.Sprintf("An agent wants to run the following command:\n\n  %s\n\nAllow it?", msg.command), &m.confirmResult)
+	m.viewMode = ConfirmDelete
+}
+
+func (m *model) resolveCommandConfirm(allowed bool) {
+	if m.pendingCommand != nil {
+		m.pendingCommand.reply <- allowed
+		m.pendingCommand = nil
+	}
+	m.viewMode = m.viewBeforeConfirm
+	m.confirmDeleteType = ""
+	m.confirmForm = nil
+}
+
+// activeTable returns the table shown in the current view, the number of
+// lines the view draws above it and the number it draws around it in total,
+// or nil when the view has no table.
+func (m *model) activeTable() (t *table.Model, above int, chrome int) {
+	switch m.viewMode {
+	case ModelView:
+		return &m.modelTable, 1, 1
+	case AvailableModelsView:
+		return &m.availableTable, 3, 3
+	case ParameterSizesView:
+		return &m.parameterSizesTable, 2, 2
+	case QuantizationView:
+		return &m.quantizationTable, 2, 2
+	case AgentView:
+		return &m.agentsTable, 2, 4
+	case ToolUsageView:
+		return &m.toolUsageTable, 2, 4
+	case SemanticSearchView:
+		return &m.semanticTable, 2, 4
+	}
+	return nil, 0, 0
+}
+
+// resizeActiveTable fits the visible table to the window, leaving room for the
+// lines its view draws above and below it.
+func (m *model) resizeActiveTable() {
+	t, _, chrome := m.activeTable()
+	if t == nil || m.height == 0 {
+		return
+	}
+
+	height := m.height - chrome
+	if height < 3 {
+		height = 3
 	}
+	t.SetWidth(m.width)
+	t.SetHeight(height)
 }
 
 func triggerWindowResize(width, height int) tea.Cmd {
@@ -1023,40 +2052,173 @@ func triggerWindowResize(width, height int) tea.Cmd {
 	}
 }
 
-func (m *model) updateViewport() {
-	var conversation strings.Builder
+func messageMarkdown(msg map[string]string, selected bool) string {
 	titleCaser := cases.Title(language.English)
+	role := titleCaser.String(msg["role"])
+	content := msg["content"]
 
-	for _, msg := range m.conversationHistory {
-		role := titleCaser.String(msg["role"])
-		content := msg["content"]
-
-		switch strings.ToLower(role) {
-		case "user":
-			conversation.WriteString(fmt.Sprintf("**%s:**\n\n%s\n\n", role, content))
-		case "assistant":
-			conversation.WriteString(fmt.Sprintf("**%s:**\n\n%s\n\n", role, content))
-		case "tool":
-			conversation.WriteString(fmt.Sprintf("**%s:**\n\n```plaintext\n%s\n```\n\n", role, content))
-		default:
-			conversation.WriteString(fmt.Sprintf("**%s:**\n\n%s\n\n", role, content))
+	header := role
+	// chats saved before responses recorded their agent have no "agent" key
+	if agent := msg["agent"]; agent != "" {
+		header = fmt.Sprintf("%s (%s)", role, agent)
+	}
+	if msg["error"] != "" {
+		header += " — failed, skipped"
+	}
+	if selected {
+		header = "▶ " + header
+	}
+
+	if files := msg["image_files"]; files != "" {
+		content = fmt.Sprintf("%s\n\n_Attached images: %s_", content, files)
+	}
+	if files := msg["attached_files"]; files != "" {
+		content = fmt.Sprintf("%s\n\n_Attached files: %s_", content, files)
+	}
+	if stats := msg["stats"]; stats != "" {
+		content = fmt.Sprintf("%s\n\n_%s_", content, stats)
+	}
+
+	switch strings.ToLower(role) {
+	case "tool":
+		return fmt.Sprintf("**%s:**\n\n```plaintext\n%s\n```\n\n", header, content)
+	default:
+		return fmt.Sprintf("**%s:**\n\n%s\n\n", header, content)
+	}
+}
+
+func conversationMarkdown(history []map[string]string) string {
+	var conversation strings.Builder
+	for _, msg := range history {
+		conversation.WriteString(messageMarkdown(msg, false))
+	}
+	return conversation.String()
+}
+
+// newRenderer builds a markdown renderer using style, which is a glamour
+// style name such as "dracula" or a path to a JSON style file. An empty or
+// "auto" style, or one that fails to load, picks dark or light from the
+// terminal background.
+func newRenderer(width int, style string) (*glamour.TermRenderer, error) {
+	if style != "" && style != "auto" {
+		renderer, err := glamour.NewTermRenderer(
+			glamour.WithStylePath(style),
+			glamour.WithWordWrap(width),
+		)
+		if err == nil {
+			return renderer, nil
 		}
+		log.Printf("Failed to load markdown style %q, using auto: %v", style, err)
 	}
+	return glamour.NewTermRenderer(
+		glamour.WithAutoStyle(),
+		glamour.WithWordWrap(width),
+	)
+}
 
-	renderedContent, err := m.renderer.Render(conversation.String())
+// fitRendererToViewport recreates the markdown renderer when the viewport
+// width or the configured style has changed since it was built, so wrapping
+// follows terminal resizes.
+func (m *model) fitRendererToViewport() {
+	if m.viewport.Width <= 0 || (m.viewport.Width == m.rendererWidth && m.config.MarkdownStyle == m.rendererStyle) {
+		return
+	}
+	renderer, err := newRenderer(m.viewport.Width, m.config.MarkdownStyle)
 	if err != nil {
-		log.Printf("Error rendering conversation: %v", err)
+		log.Printf("Error creating markdown renderer: %v", err)
+		return
+	}
+	m.renderer = renderer
+	m.rendererWidth = m.viewport.Width
+	m.rendererStyle = m.config.MarkdownStyle
+}
+
+// updateViewport renders each message separately so the line offset of every
+// message is known, which message selection uses to scroll to a message.
+func (m *model) updateViewport() {
+	m.fitRendererToViewport()
+
+	var rendered strings.Builder
+	m.messageOffsets = m.messageOffsets[:0]
+
+	for i, msg := range m.conversationHistory {
+		m.messageOffsets = append(m.messageOffsets, strings.Count(rendered.String(), "\n"))
+
+		selected := m.viewMode == MessageSelectView && i == m.selectedMessage
+		if m.config.ShowTimestamps {
+			rendered.WriteString(messageTimeLabel(msg, m.viewport.Width))
+		}
+		if m.rawOutput {
+			rendered.WriteString(ansi.Hardwrap(messageMarkdown(msg, selected), m.viewport.Width, true))
+			continue
+		}
+		renderedMessage, err := m.renderer.Render(messageMarkdown(msg, selected))
+		if err != nil {
+			log.Printf("Error rendering conversation: %v", err)
+			return
+		}
+		rendered.WriteString(renderedMessage)
+	}
+
+	m.renderedHistory = rendered.String()
+	m.refreshViewportContent()
+	m.viewport.Height = m.height - 5
+
+	if m.viewMode == MessageSelectView && m.selectedMessage < len(m.messageOffsets) {
+		m.viewport.SetYOffset(m.messageOffsets[m.selectedMessage])
+	}
+}
+
+// refreshViewportContent sets the viewport to the rendered conversation,
+// followed by a thinking indicator while a response is pending. It is cheap
+// enough to call on every spinner tick.
+func (m *model) refreshViewportContent() {
+	content := m.renderedHistory
+	if m.loading {
+		content += fmt.Sprintf("\n  %s Assistant is thinking...\n", m.spinner.View())
+	}
+
+	m.searchMatches = searchMatchLines(content, m.searchQuery)
+	if len(m.searchMatches) == 0 {
+		m.viewport.SetContent(content)
+		m.viewport.GotoBottom()
 		return
 	}
-	m.viewport.SetContent(renderedContent)
-	m.viewport.GotoBottom()
-	m.viewport.Height = m.height - 3
+
+	if m.searchIndex >= len(m.searchMatches) {
+		m.searchIndex = len(m.searchMatches) - 1
+	}
+	line := m.searchMatches[m.searchIndex]
+	m.viewport.SetContent(highlightSearchMatches(content, m.searchQuery, line))
+	m.viewport.SetYOffset(line - m.viewport.Height/2)
 }
 
 func main() {
-	model := InitialModel()
+	dataDir := flag.String("data-dir", defaultDataDir, "directory for config, agents, chats and other state")
+	apiLogPath := flag.String("api-log", os.Getenv("AGENTUI_API_LOG"), "file to append raw Ollama chat requests and responses to, for debugging")
+	flag.Parse()
+
+	if *apiLogPath != "" {
+		f, err := openAPILog(*apiLogPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+	}
+
+	if err := os.MkdirAll(*dataDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create data directory %s: %v\n", *dataDir, err)
+		os.Exit(1)
+	}
+
+	model := InitialModel(*dataDir)
 	model.viewMode = ChatView // Ensure we start in ChatView
-	p := tea.NewProgram(model)
+	p := tea.NewProgram(model, tea.WithMouseCellMotion())
+	model.program = p
+	onRetry = func(attempt int, maxAttempts int, err error) {
+		p.Send(retryMsg{attempt: attempt, maxAttempts: maxAttempts, err: err})
+	}
 	if _, err := p.Run(); err != nil {
 		os.Exit(1)
 	}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 func (m *model) refreshModelView() tea.Cmd {
@@ -34,6 +39,13 @@ func (m *model) refreshModelView() tea.Cmd {
 	)
 }
 
+// refreshModels fetches the installed models, showing the spinner until
+// modelsMsg arrives, and re-checks whether Ollama is up.
+func (m *model) refreshModels() tea.Cmd {
+	m.modelsLoading = true
+	return tea.Batch(fetchModelsCmd(), checkOllamaCmd())
+}
+
 func fetchModelsCmd() tea.Cmd {
 	return func() tea.Msg {
 		models, err := fetchModels()
@@ -75,6 +87,91 @@ func (m *model) populateModelTable(models []OllamaModel) {
 	}
 }
 
+func setupAvailableFilterInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "Filter: "
+	ti.Placeholder = "model name"
+	return ti
+}
+
+func (m *model) startAvailableFilter() tea.Cmd {
+	m.availableFilterActive = true
+	m.availableFilter.CursorEnd()
+	return m.availableFilter.Focus()
+}
+
+// updateAvailableFilter narrows the library as the filter is typed. Enter
+// keeps the filter and esc clears it.
+func (m *model) updateAvailableFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.availableFilterActive = false
+		m.availableFilter.Blur()
+		return m, nil
+	case "esc":
+		m.availableFilterActive = false
+		m.availableFilter.Blur()
+		m.availableFilter.SetValue("")
+		m.applyAvailableFilter()
+		return m, nil
+	}
+
+	previous := m.availableFilter.Value()
+	var cmd tea.Cmd
+	m.availableFilter, cmd = m.availableFilter.Update(msg)
+	if m.availableFilter.Value() != previous {
+		m.applyAvailableFilter()
+	}
+	return m, cmd
+}
+
+// applyAvailableFilter recomputes the models matching the filter and shows
+// the first page of them.
+func (m *model) applyAvailableFilter() {
+	query := strings.ToLower(strings.TrimSpace(m.availableFilter.Value()))
+
+	m.availableFiltered = m.availableFiltered[:0]
+	for _, mdl := range m.availableModels {
+		if query == "" || strings.Contains(strings.ToLower(mdl.Name), query) {
+			m.availableFiltered = append(m.availableFiltered, mdl)
+		}
+	}
+
+	m.availablePage = 0
+	m.showAvailablePage()
+}
+
+// availablePageSize is the number of rows the available models table can
+// show at once.
+func (m *model) availablePageSize() int {
+	if size := m.availableTable.Height(); size > 0 {
+		return size
+	}
+	return 10
+}
+
+func (m *model) availablePageCount() int {
+	size := m.availablePageSize()
+	pages := (len(m.availableFiltered) + size - 1) / size
+	if pages == 0 {
+		return 1
+	}
+	return pages
+}
+
+// showAvailablePage puts only the current page into the table so large
+// libraries stay quick to render and scroll.
+func (m *model) showAvailablePage() {
+	m.availablePage = max(0, min(m.availablePage, m.availablePageCount()-1))
+
+	size := m.availablePageSize()
+	start := m.availablePage * size
+	end := min(start+size, len(m.availableFiltered))
+
+	m.populateAvailableModelsTable(m.availableFiltered[start:end])
+	m.availableTable.SetCursor(0)
+}
+
 func (m *model) populateAvailableModelsTable(models []AvailableModel) {
 	var rows []table.Row
 	for _, mdl := range models {
@@ -103,6 +200,22 @@ func (m *model) populateParameterSizesTable(sizes []string) {
 	}
 }
 
+func (m *model) populateQuantizationTable() {
+	rows := []table.Row{{m.selectedModelSize + " (default)"}}
+	for _, tag := range m.quantizationTags {
+		rows = append(rows, table.Row{tag})
+	}
+	m.quantizationTable.SetRows(rows)
+	m.quantizationTable.SetCursor(0)
+}
+
+func fetchModelTagsCmd(name string) tea.Cmd {
+	return func() tea.Msg {
+		tags, err := scrapeModelTags(name)
+		return modelTagsMsg{tags: tags, err: err}
+	}
+}
+
 func deleteModelCmd(modelName string) tea.Cmd {
 	return func() tea.Msg {
 		err := deleteModel(modelName)
@@ -113,21 +226,286 @@ func deleteModelCmd(modelName string) tea.Cmd {
 	}
 }
 
-func fetchAvailableModelsCmd() tea.Cmd {
+// fetchAvailableModelsCmd serves the library from the cache at cachePath
+// while it is younger than ttl, and falls back to a stale cache when scraping
+// fails.
+func fetchAvailableModelsCmd(cachePath string, ttl time.Duration, forceRefresh bool) tea.Cmd {
 	return func() tea.Msg {
+		cache, cacheErr := loadLibraryCache(cachePath)
+		if cacheErr == nil && !forceRefresh && time.Since(cache.FetchedAt) < ttl {
+			return availableModelsMsg{models: cache.Models, cached: true, fetchedAt: cache.FetchedAt}
+		}
+
 		models, err := scrapeOllamaLibrary()
 		if err != nil {
+			if cacheErr == nil && len(cache.Models) > 0 {
+				log.Printf("Scraping library failed, using cache: %v", err)
+				return availableModelsMsg{models: cache.Models, cached: true, fetchedAt: cache.FetchedAt}
+			}
 			return errMsg(err)
 		}
-		return availableModelsMsg(models)
+
+		if err := saveLibraryCache(cachePath, models); err != nil {
+			log.Printf("Failed to save library cache: %v", err)
+		}
+		return availableModelsMsg{models: models, fetchedAt: time.Now()}
+	}
+}
+
+func (m *model) libraryCacheTTL() time.Duration {
+	if m.config.LibraryCacheTTL == "" {
+		return defaultLibraryCacheTTL
+	}
+	ttl, err := time.ParseDuration(m.config.LibraryCacheTTL)
+	if err != nil || ttl < 0 {
+		log.Printf("Invalid library cache TTL %q, using default", m.config.LibraryCacheTTL)
+		return defaultLibraryCacheTTL
 	}
+	return ttl
 }
 
-func downloadModelCmd(modelName string) tea.Cmd {
+func (m model) availableModelsView() string {
+	header := "Available Ollama Models:"
+	if m.availableModelsCached {
+		header = fmt.Sprintf("Available Ollama Models (cached %s ago, press 'r' to refresh):",
+			time.Since(m.availableModelsFetched).Round(time.Minute))
+	}
+
+	status := lipgloss.NewStyle().Foreground(activeTheme.Muted).Render(fmt.Sprintf(
+		"Page %d/%d (%d models) — pgup/pgdn to page, / to filter",
+		m.availablePage+1, m.availablePageCount(), len(m.availableFiltered)))
+	if m.availableFilterActive {
+		status = m.availableFilter.View()
+	} else if filter := m.availableFilter.Value(); filter != "" {
+		status = lipgloss.NewStyle().Foreground(activeTheme.Muted).Render(fmt.Sprintf(
+			"Page %d/%d (%d models matching %q) — pgup/pgdn to page, / to filter, esc in the filter to clear",
+			m.availablePage+1, m.availablePageCount(), len(m.availableFiltered), filter))
+	}
+
+	return header + "\n" + status + "\n\n" + m.availableTable.View()
+}
+
+// downloadModelCmd starts the pull in the background and streams each
+// PullResponse back to Update as a pullProgressMsg.
+func downloadModelCmd(ctx context.Context, modelName string) tea.Cmd {
+	return streamProgressCmd(ctx, modelName, "failed to download model", func(onProgress func(PullResponse)) error {
+		return downloadModel(ctx, modelName, onProgress)
+	})
+}
+
+// createModelCmd builds modelName from a Modelfile in the background,
+// streaming progress the same way as downloadModelCmd.
+func createModelCmd(ctx context.Context, modelName string, modelfilePath string) tea.Cmd {
+	return streamProgressCmd(ctx, modelName, "failed to create model", func(onProgress func(PullResponse)) error {
+		return createModel(ctx, modelName, modelfilePath, onProgress)
+	})
+}
+
+// streamProgressCmd runs run in the background, relaying its progress. A run
+// stopped by cancelling ctx ends quietly, since cancelDownload has already
+// told the user.
+func streamProgressCmd(ctx context.Context, modelName string, failure string, run func(func(PullResponse)) error) tea.Cmd {
 	return func() tea.Msg {
-		if err := downloadModel(modelName); err != nil {
-			return errMsg(fmt.Errorf("failed to download model: %w", err))
+		ch := make(chan tea.Msg)
+
+		go func() {
+			defer close(ch)
+			err := run(func(p PullResponse) {
+				ch <- pullProgressMsg{PullResponse: p, ch: ch}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				ch <- errMsg(fmt.Errorf("%s: %w", failure, err))
+				return
+			}
+			ch <- modelDownloadedMsg(modelName)
+		}()
+
+		return <-ch
+	}
+}
+
+func waitForPullProgress(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+func (m *model) resetDownloadProgress(modelName string) tea.Cmd {
+	m.downloadingModel = modelName
+	m.pullStatus = ""
+	m.pullTotal = 0
+	m.pullCompleted = 0
+	return m.downloadProgress.SetPercent(0)
+}
+
+// startDownload switches to the download progress view and starts pulling
+// modelName.
+func (m *model) startDownload(modelName string) tea.Cmd {
+	m.viewMode = DownloadingView
+	m.progressVerb = "Downloading"
+	ctx := m.newPullContext()
+	return tea.Batch(m.resetDownloadProgress(modelName), downloadModelCmd(ctx, modelName), m.spinner.Tick)
+}
+
+// startCreate switches to the progress view and builds modelName from the
+// Modelfile at modelfilePath.
+func (m *model) startCreate(modelName string, modelfilePath string) tea.Cmd {
+	m.viewMode = DownloadingView
+	m.progressVerb = "Creating"
+	ctx := m.newPullContext()
+	return tea.Batch(m.resetDownloadProgress(modelName), createModelCmd(ctx, modelName, modelfilePath), m.spinner.Tick)
+}
+
+// newPullContext returns the context for a new download or create, keeping
+// its cancel func for cancelDownload.
+func (m *model) newPullContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelPull = cancel
+	return ctx
+}
+
+// cancelDownload aborts the download or create shown in DownloadingView and
+// returns to the model list.
+func (m *model) cancelDownload() tea.Cmd {
+	if m.cancelPull != nil {
+		m.cancelPull()
+		m.cancelPull = nil
+	}
+
+	notice := fmt.Sprintf("Download of %s cancelled.", m.downloadingModel)
+	if m.progressVerb == "Creating" {
+		notice = fmt.Sprintf("Creating %s cancelled.", m.downloadingModel)
+	}
+	m.viewMode = ModelView
+	m.modelTable.Focus()
+	return tea.Batch(m.refreshModels(), m.showToast(notice))
+}
+
+func (m model) downloadingView() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s %s — esc to cancel\n\n", m.spinner.View(), m.progressVerb, m.downloadingModel))
+	b.WriteString(m.downloadProgress.View())
+	b.WriteString("\n\n")
+
+	if m.pullStatus != "" {
+		b.WriteString(m.pullStatus)
+		b.WriteString("\n")
+	}
+	if m.pullTotal > 0 {
+		b.WriteString(fmt.Sprintf("%s / %s", FormatSizeGB(m.pullCompleted), FormatSizeGB(m.pullTotal)))
+	}
+
+	return b.String()
+}
+
+// hoveredModel returns the installed model under the cursor, if any.
+func (m *model) hoveredModel() (OllamaModel, bool) {
+	row := m.modelTable.SelectedRow()
+	if row == nil {
+		return OllamaModel{}, false
+	}
+	for _, mdl := range m.installedModels {
+		if mdl.Name == row[0] {
+			return mdl, true
+		}
+	}
+	return OllamaModel{}, false
+}
+
+// openModelDetails looks up the hovered model with /show in the background;
+// the detail view opens when modelDetailsMsg arrives.
+func (m *model) openModelDetails() tea.Cmd {
+	mdl, ok := m.hoveredModel()
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		info, err := showModel(mdl.Name)
+		return modelDetailsMsg{model: mdl, info: info, err: err}
+	}
+}
+
+func (m *model) showModelDetails(mdl OllamaModel, info *ModelInfo) {
+	m.previewViewport.Width = m.width
+	m.previewViewport.Height = m.height - 2
+	m.previewViewport.SetContent(lipgloss.NewStyle().Width(m.width).Render(modelDetailContent(mdl, info)))
+	m.previewViewport.GotoTop()
+	m.viewMode = ModelDetailView
+	m.modelTable.Blur()
+}
+
+func modelDetailContent(mdl OllamaModel, info *ModelInfo) string {
+	headerStyle := lipgloss.NewStyle().Foreground(activeTheme.Accent).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(activeTheme.Muted)
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(mdl.Name))
+	b.WriteString("\n\n")
+
+	fields := [][2]string{
+		{"Digest", mdl.Digest},
+		{"Size", FormatSizeGB(mdl.Size)},
+		{"Parameters", mdl.Details.ParameterSize},
+		{"Quantization", mdl.Details.QuantizationLevel},
+		{"Family", mdl.Details.Family},
+		{"Format", mdl.Details.Format},
+		{"Modified", mdl.ModifiedAt.Local().Format("2006-01-02 15:04")},
+	}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		b.WriteString(labelStyle.Render(fmt.Sprintf("%-14s", field[0])))
+		b.WriteString(field[1])
+		b.WriteString("\n")
+	}
+
+	if len(info.ModelInfo) > 0 {
+		keys := make([]string, 0, len(info.ModelInfo))
+		for key := range info.ModelInfo {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("\n")
+		b.WriteString(headerStyle.Render("Model info"))
+		b.WriteString("\n")
+		for _, key := range keys {
+			b.WriteString(labelStyle.Render(key + ": "))
+			b.WriteString(fmt.Sprint(info.ModelInfo[key]))
+			b.WriteString("\n")
 		}
-		return modelDownloadedMsg(modelName)
 	}
+
+	sections := [][2]string{
+		{"Parameters", info.Parameters},
+		{"System", info.System},
+		{"Template", info.Template},
+		{"License", info.License},
+	}
+	for _, section := range sections {
+		if strings.TrimSpace(section[1]) == "" {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(headerStyle.Render(section[0]))
+		b.WriteString("\n")
+		b.WriteString(strings.TrimSpace(section[1]))
+		b.WriteString("\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (m model) modelDetailView() string {
+	hint := lipgloss.NewStyle().Foreground(activeTheme.Muted).
+		Render("Model details — j/k to scroll, esc to go back.")
+	return hint + "\n\n" + m.previewViewport.View()
 }
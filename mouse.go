@@ -0,0 +1,122 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const mouseWheelLines = 3
+
+// handleMouse scrolls the chat with the wheel and moves table and list
+// cursors to the clicked row.
+func (m *model) handleMouse(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		up := msg.Button == tea.MouseButtonWheelUp
+		switch m.viewMode {
+		case ChatView, InsertView, MessageSelectView:
+			if up {
+				m.viewport.LineUp(mouseWheelLines)
+			} else {
+				m.viewport.LineDown(mouseWheelLines)
+			}
+		default:
+			if m.viewMode == ChatListView && m.chatList.FilterState() == list.Filtering {
+				return
+			}
+			if up {
+				m.navigate("up")
+			} else {
+				m.navigate("down")
+			}
+		}
+
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return
+		}
+		if t, above, _ := m.activeTable(); t != nil {
+			m.clickTableRow(t, above, msg.Y)
+			return
+		}
+		if m.viewMode == ChatListView {
+			m.clickChatListItem(msg.Y)
+		}
+	}
+}
+
+// followTableCursor updates the recorded first visible row of the current
+// view's table after its cursor moves. The table scrolls only as far as it
+// takes to keep the cursor on screen, and doesn't expose where it scrolled to,
+// so clicks rely on this record to find the row under the mouse.
+func (m *model) followTableCursor() {
+	t, _, _ := m.activeTable()
+	if t == nil {
+		return
+	}
+	if m.tableOffsets == nil {
+		m.tableOffsets = make(map[viewMode]int)
+	}
+	m.tableOffsets[m.viewMode] = visibleRowOffset(m.tableOffsets[m.viewMode], t.Cursor(), t.Height(), len(t.Rows()))
+}
+
+// visibleRowOffset returns the first visible row of a table showing height
+// rows, scrolled from offset just far enough to keep cursor in view.
+func visibleRowOffset(offset, cursor, height, rows int) int {
+	if height <= 0 {
+		return 0
+	}
+	offset = min(offset, cursor)
+	offset = max(offset, cursor-height+1)
+	return max(min(offset, rows-height), 0)
+}
+
+// clickTableRow selects the row drawn on screen line y, where the view draws
+// above lines before the table.
+func (m *model) clickTableRow(t *table.Model, above int, y int) {
+	m.followTableCursor()
+
+	// the table's view is its header followed by exactly Height() row lines
+	header := lipgloss.Height(t.View()) - t.Height()
+	line := y - above - header
+	if line < 0 || line >= t.Height() {
+		return
+	}
+	row := m.tableOffsets[m.viewMode] + line
+	if row >= len(t.Rows()) {
+		return
+	}
+	t.SetCursor(row)
+	m.followTableCursor()
+}
+
+// clickChatListItem selects the chat drawn on screen line y, including its
+// description line and the blank line below it. Project headers can't be
+// selected.
+func (m *model) clickChatListItem(y int) {
+	if m.chatList.FilterState() == list.Filtering {
+		return
+	}
+
+	// the list draws its title bar above the items
+	line := y - lipgloss.Height(m.chatListHeader()) - 1 - m.chatList.Styles.TitleBar.GetVerticalFrameSize()
+	if line < 0 {
+		return
+	}
+
+	delegate := newChatDelegate(m.markedChats)
+	visible := m.chatList.VisibleItems()
+	start, end := m.chatList.Paginator.GetSliceBounds(len(visible))
+	for i := start; i < end; i++ {
+		height := delegate.itemHeight(visible[i])
+		if line < height {
+			if _, ok := visible[i].(chatItem); ok {
+				m.chatList.Select(i)
+			}
+			return
+		}
+		line -= height + delegate.Spacing()
+	}
+}
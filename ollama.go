@@ -2,61 +2,211 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	// Imports
 )
 
-func (m *model) toggleOllamaServe() tea.Cmd {
+// ollamaAPIError is a non-200 response from Ollama, carrying the human
+// readable message from its {"error": "..."} body.
+type ollamaAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ollamaAPIError) Error() string {
+	return fmt.Sprintf("Ollama API error: %s", e.Message)
+}
+
+// parseOllamaError builds an ollamaAPIError from a failed response, falling
+// back to the raw body or status when the body isn't Ollama's error JSON.
+func parseOllamaError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error != "" {
+		message = apiErr.Error
+	}
+	if message == "" {
+		message = resp.Status
+	}
+
+	return &ollamaAPIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+var modelNotFoundPattern = regexp.MustCompile(`model ['"]?([^'"\s]+)['"]? not found`)
+
+// missingModelName returns the model named in a "model not found" error.
+func missingModelName(err error) (string, bool) {
+	var apiErr *ollamaAPIError
+	if !errors.As(err, &apiErr) {
+		return "", false
+	}
+	match := modelNotFoundPattern.FindStringSubmatch(apiErr.Message)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// ollamaReachable reports whether the Ollama API answers, which is the real
+// test of whether the server is up regardless of who started it.
+func ollamaReachable() bool {
+	resp, err := healthHTTPClient.Get(ollamaAPIURL + "/tags")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func checkOllamaCmd() tea.Cmd {
 	return func() tea.Msg {
-		if m.ollamaRunning {
-			err := exec.Command("pkill", "-f", "ollama serve").Run()
-			if err != nil {
-				return errMsg(fmt.Errorf("failed to stop Ollama: %w", err))
-			}
-		} else {
-			err := exec.Command("ollama", "serve").Start()
-			if err != nil {
-				return errMsg(fmt.Errorf("failed to start Ollama: %w", err))
-			}
+		return ollamaStatusMsg(ollamaReachable())
+	}
+}
+
+func ollamaHealthCmd() tea.Cmd {
+	return func() tea.Msg {
+		return ollamaHealthMsg(ollamaReachable())
+	}
+}
+
+// scheduleOllamaHealthCheck re-checks the server after interval so the
+// indicator follows servers that crash, or are started or stopped outside
+// agentui. A zero interval turns the checks off.
+func scheduleOllamaHealthCheck(interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return ollamaHealthTickMsg{}
+	})
+}
+
+// healthCheckInterval is how often the background health check probes
+// Ollama, from the health_check_interval setting.
+func (m *model) healthCheckInterval() time.Duration {
+	if m.config.HealthInterval == "" {
+		return defaultHealthInterval
+	}
+	interval, err := time.ParseDuration(m.config.HealthInterval)
+	if err != nil || interval < 0 {
+		log.Printf("Invalid health check interval %q, using default", m.config.HealthInterval)
+		return defaultHealthInterval
+	}
+	return interval
+}
+
+// waitForOllama polls until the server's reachability matches running or
+// ollamaToggleTimeout passes, and returns the last observed state.
+func waitForOllama(running bool) bool {
+	deadline := time.Now().Add(ollamaToggleTimeout)
+	for {
+		reachable := ollamaReachable()
+		if reachable == running || time.Now().After(deadline) {
+			return reachable
 		}
-		return OllamaToggledMsg{}
+		time.Sleep(250 * time.Millisecond)
 	}
 }
 
-func processAgentChain(input string, m *model, agent Agent) (string, error) {
-	var contextContent string
-	var err error
+func startOllamaCmd() tea.Cmd {
+	return func() tea.Msg {
+		if err := exec.Command("ollama", "serve").Start(); err != nil {
+			return errMsg(fmt.Errorf("failed to start Ollama: %w", err))
+		}
+		if !waitForOllama(true) {
+			return errMsg(fmt.Errorf("started Ollama but it didn't respond within %s", ollamaToggleTimeout))
+		}
+		return ollamaStatusMsg(true)
+	}
+}
 
-	if agent.UseContext && agent.ContextFilePath != "" && agent.ContextFilePath != "No context file selected" {
-		contextContent, err = loadFileContext(agent.ContextFilePath)
-		if err != nil {
-			return "", fmt.Errorf("failed to load context for agent '%s': %w", agent.Role, err)
+func stopOllamaCmd() tea.Cmd {
+	return func() tea.Msg {
+		if err := exec.Command("pkill", "-f", "ollama serve").Run(); err != nil {
+			return errMsg(fmt.Errorf("failed to stop Ollama: %w", err))
+		}
+		if waitForOllama(false) {
+			return errMsg(fmt.Errorf("Ollama is still responding; it may be managed outside agentui"))
 		}
+		return ollamaStatusMsg(false)
 	}
+}
 
-	var systemPrompt string
+// startOllamaAndRetryCmd starts the server for the error screen's retry,
+// reporting ollamaRestartedMsg once it answers.
+func startOllamaAndRetryCmd() tea.Cmd {
+	start := startOllamaCmd()
+	return func() tea.Msg {
+		if msg, ok := start().(errMsg); ok {
+			return msg
+		}
+		return ollamaRestartedMsg{}
+	}
+}
 
-	hasCodeChecker := false
+// isConnectionRefused reports whether err means nothing is listening on the
+// Ollama port, i.e. the server isn't running.
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(err.Error(), "connection refused")
+}
+
+// toggleOllamaServe starts a stopped server, or asks before stopping a
+// running one since other clients and downloads may depend on it.
+func (m *model) toggleOllamaServe() tea.Cmd {
+	if !m.ollamaRunning {
+		return startOllamaCmd()
+	}
+
+	m.viewBeforeConfirm = m.viewMode
+	m.confirmDeleteType = "ollama"
+	m.confirmForm = createConfirmForm("Stop the Ollama server? Other clients using it and any download in progress will be cut off.", &m.confirmResult)
+	m.viewMode = ConfirmDelete
+	return nil
+}
+
+// reviewsGoCode reports whether the agent has the Go checker and the input
+// contains Go code.
+func reviewsGoCode(agent Agent, languages map[string]bool) bool {
+	if !languages["go"] {
+		return false
+	}
 	for _, tool := range agent.Tools {
-		if tool.Name == "check_go_code" {
-			hasCodeChecker = true
-			break
+		if tool.Name == checkGoCodeTool.Name {
+			return true
 		}
 	}
+	return false
+}
 
-	codeBlocks := extractCodeBlocks(input)
-	hasCode := len(codeBlocks) > 0
+// buildSystemPrompt returns the agent's system prompt with contextContent
+// filled in. Agents holding the Go checker get the code review prompt instead
+// when input contains Go code.
+func buildSystemPrompt(agent Agent, contextContent string, input string) string {
+	var systemPrompt string
 
 	// if an agent is given golinter tool and go code is detected, system prompt is overridden
-	if hasCodeChecker && hasCode {
+	if reviewsGoCode(agent, codeLanguages(extractCodeBlocks(input))) {
 		systemPrompt = `You are a code review assistant. Your primary task is to analyze and test Go code.
 Follow these steps for each code review:
 
@@ -101,81 +251,234 @@ Important: Always use the check_go_code tool on any Go code you receive. Do not
 		}
 	}
 
+	return systemPrompt
+}
+
+// buildMessages assembles the messages sent to an agent: the system prompt
+// with its context file, the conversation history if the agent uses it, and
+// the input as the user message.
+func buildMessages(agent Agent, input string, history []map[string]string) ([]map[string]string, error) {
+	var contextContent string
+	var err error
+
+	if agent.UseContext && agent.ContextFilePath != "" && agent.ContextFilePath != "No context file selected" {
+		contextContent, err = loadFileContext(agent.ContextFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load context for agent '%s': %w", agent.Role, err)
+		}
+	}
+
 	var messages []map[string]string
 	messages = append(messages, map[string]string{
 		"role":    "system",
-		"content": systemPrompt,
+		"content": buildSystemPrompt(agent, contextContent, input),
 	})
 
 	if agent.UseConversation {
-		messages = append(messages, m.conversationHistory...)
+		for _, msg := range history {
+			if msg["error"] == "" {
+				messages = append(messages, msg)
+			}
+		}
 	}
 
 	messages = append(messages, map[string]string{
 		"role":    "user",
 		"content": input,
 	})
+	return messages, nil
+}
+
+// fitContextWindow drops the oldest history messages until the estimated
+// size fits in contextWindow tokens, so Ollama doesn't silently cut the front
+// of the prompt. The system prompt and the new user message are always kept.
+// Depending on the agent's ContextOverflow setting the dropped messages are
+// replaced by a note or by a summary. The returned warning is shown above the
+// response when anything was dropped.
+func fitContextWindow(messages []map[string]string, agent Agent, contextWindow int) ([]map[string]string, string) {
+	if estimateHistoryTokens(messages) <= contextWindow || len(messages) <= 2 {
+		return messages, ""
+	}
+
+	system := messages[0]
+	history := messages[1 : len(messages)-1]
+	user := messages[len(messages)-1]
+
+	// leave room for the note or summary that replaces the dropped messages
+	budget := contextWindow - estimateTokens(system["content"]) - estimateTokens(user["content"])
+	if agent.ContextOverflow == overflowSummarize {
+		budget -= contextWindow / 4
+	} else {
+		budget -= estimateTokens(omittedMessagesNote)
+	}
+
+	drop := 0
+	for drop < len(history) && estimateHistoryTokens(history[drop:]) > budget {
+		drop++
+	}
+	dropped, kept := history[:drop], history[drop:]
+
+	replacement := map[string]string{"role": "system", "content": omittedMessagesNote}
+	warning := fmt.Sprintf("_%d earlier message(s) were omitted to fit the %d token context window._\n\n", len(dropped), contextWindow)
+
+	if agent.ContextOverflow == overflowSummarize {
+		summary, err := summarizeMessages(dropped, agent, contextWindow)
+		if err != nil {
+			log.Printf("Failed to summarize earlier messages for agent '%s', dropping them instead: %v", agent.Role, err)
+		} else {
+			replacement["content"] = "Summary of earlier messages:\n" + summary
+			warning = fmt.Sprintf("_%d earlier message(s) were summarized to fit the %d token context window._\n\n", len(dropped), contextWindow)
+		}
+	}
+
+	fitted := make([]map[string]string, 0, len(kept)+3)
+	fitted = append(fitted, system, replacement)
+	fitted = append(fitted, kept...)
+	fitted = append(fitted, user)
+	return fitted, warning
+}
+
+// summarizeMessages asks the agent's model for a short summary of messages,
+// keeping only as much of the most recent text as fits in the context window.
+func summarizeMessages(messages []map[string]string, agent Agent, contextWindow int) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", msg["role"], msg["content"])
+	}
+
+	text := transcript.String()
+	if limit := contextWindow * 3; len(text) > limit {
+		start := len(text) - limit
+		for start < len(text) && !utf8.RuneStart(text[start]) {
+			start++
+		}
+		text = text[start:]
+	}
+
+	summaryAgent := agent
+	summaryAgent.Tokens = strconv.Itoa(contextWindow)
+	return requestOllama([]map[string]string{
+		{
+			"role":    "system",
+			"content": "Summarize the following conversation in a few sentences, keeping any facts, decisions and code details needed to continue it.",
+		},
+		{
+			"role":    "user",
+			"content": text,
+		},
+	}, summaryAgent)
+}
+
+// String summarises the stats as e.g. "128 tokens in 3.2s — 40 tok/s", or
+// returns "" if Ollama didn't report a token count.
+func (s responseStats) String() string {
+	if s.EvalCount == 0 {
+		return ""
+	}
+	summary := fmt.Sprintf("%d tokens in %.1fs", s.EvalCount, s.TotalDuration.Seconds())
+	if s.EvalDuration > 0 {
+		summary += fmt.Sprintf(" — %.0f tok/s", float64(s.EvalCount)/s.EvalDuration.Seconds())
+	}
+	return summary
+}
+
+// responseHeader starts every agent reply, naming the agent that wrote it.
+func responseHeader(role string) string {
+	return fmt.Sprintf("Response from %s:\n\n", role)
+}
+
+// processAgentChain sends input to a single agent, along with the request's
+// history if the agent uses the conversation, and runs any tools it calls.
+// If the agent's model isn't installed and it names a FallbackModel, the
+// request is retried once with that model instead.
+func processAgentChain(input string, req chatRequest, agent Agent) (string, responseStats, error) {
+	response, stats, err := queryAgent(input, req, agent)
+	missing, ok := missingModelName(err)
+	fallback := strings.TrimSpace(agent.FallbackModel)
+	if !ok || fallback == "" || fallback == agent.ModelVersion {
+		return response, stats, err
+	}
+
+	log.Printf("Model %s for agent '%s' is not installed, falling back to %s", missing, agent.Role, fallback)
+	agent.fallbackFrom = agent.ModelVersion
+	agent.ModelVersion = fallback
+	return queryAgent(input, req, agent)
+}
+
+func queryAgent(input string, req chatRequest, agent Agent) (string, responseStats, error) {
+	agent = inheritSystemPrompt(agent, req.projectPrompt)
+	messages, err := buildMessages(agent, input, req.history)
+	if err != nil {
+		return "", responseStats{}, err
+	}
+	if len(req.images) > 0 {
+		messages[len(messages)-1]["images"] = strings.Join(req.images, ",")
+	}
 
-	contextWindow, err := strconv.Atoi(agent.Tokens)
-	if err != nil || contextWindow <= 0 {
-		contextWindow = 2048
+	languages := codeLanguages(extractCodeBlocks(input))
+
+	contextWindow := agent.contextWindow()
+
+	var overflowWarning string
+	messages, overflowWarning = fitContextWindow(messages, agent, contextWindow)
+
+	options := map[string]interface{}{
+		"num_ctx": contextWindow,
 	}
+	agent.applySamplingOptions(options)
 
 	payload := map[string]interface{}{
 		"model":    agent.ModelVersion,
-		"messages": messages,
+		"messages": toAPIMessages(messages),
 		"stream":   false,
-		"options": map[string]interface{}{
-			"num_ctx": contextWindow,
-		},
+		"options":  options,
 	}
 
-	// WIP: mm support
-	if strings.Contains(agent.ModelVersion, "llava") || strings.Contains(agent.ModelVersion, "bakllava") {
-		for _, msg := range messages {
-			if strings.Contains(msg["content"], "![") && strings.Contains(msg["content"], "](data:image") {
-				payload["model"] = agent.ModelVersion
-				break
-			}
-		}
+	var imageWarning string
+	if hasImages(messages) && !isVisionModel(agent.ModelVersion, req.visionModels) {
+		messages = stripImages(messages)
+		payload["messages"] = toAPIMessages(messages)
+		imageWarning = fmt.Sprintf("_Images were not sent: %s is not a known vision model._\n\n", agent.ModelVersion)
+		log.Printf("Stripped images for agent '%s': model %s is not a known vision model", agent.Role, agent.ModelVersion)
 	}
 
-	if hasCodeChecker && hasCode {
-		payload["tools"] = []map[string]interface{}{
-			{
-				"type": "function",
-				"function": map[string]interface{}{
-					"name":        "check_go_code",
-					"description": "Check Go code for errors and style issues using golint.",
-					"parameters": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"code": map[string]interface{}{
-								"type":        "string",
-								"description": "The Go code to check for errors.",
-							},
-						},
-						"required": []string{"code"},
-					},
-				},
-			},
+	var toolDefinitions []map[string]interface{}
+	for _, agentTool := range agent.Tools {
+		tool, ok := req.tools.lookup(agentTool.Name)
+		if !ok {
+			continue
+		}
+		// checkers are only offered when the input contains their language
+		if language, ok := checkerLanguage(tool.Name); ok && !languages[language] {
+			continue
 		}
+		toolDefinitions = append(toolDefinitions, tool.payload())
+	}
+	if len(toolDefinitions) > 0 {
+		payload["tools"] = toolDefinitions
+	}
+	if format := agent.outputFormat(); format != nil {
+		payload["format"] = format
 	}
 
 	requestBody, err := json.Marshal(payload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+		return "", responseStats{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	resp, err := http.Post(ollamaAPIURL+"/chat", "application/json", bytes.NewBuffer(requestBody))
+	ctx, cancel := agent.requestContext()
+	defer cancel()
+
+	logAPIRequest(ollamaAPIURL+"/chat", requestBody)
+	resp, err := postJSONWithContext(ctx, ollamaAPIURL+"/chat", requestBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request to Ollama API: %w", err)
+		return "", responseStats{}, agent.timeoutError(ctx, fmt.Errorf("failed to send request to Ollama API: %w", err))
 	}
 	defer resp.Body.Close()
+	logAPIResponse(ollamaAPIURL+"/chat", resp)
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Ollama API error: %s", string(body))
+		return "", responseStats{}, parseOllamaError(resp)
 	}
 
 	var apiResponse struct {
@@ -189,112 +492,204 @@ Important: Always use the check_go_code tool on any Go code you receive. Do not
 				} `json:"function"`
 			} `json:"tool_calls"`
 		} `json:"message"`
+		TotalDuration int64 `json:"total_duration"`
+		EvalCount     int   `json:"eval_count"`
+		EvalDuration  int64 `json:"eval_duration"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return "", fmt.Errorf("failed to decode Ollama API response: %w", err)
+		return "", responseStats{}, agent.timeoutError(ctx, fmt.Errorf("failed to decode Ollama API response: %w", err))
+	}
+
+	// a reply that only calls tools has no content to check
+	if agent.ForceJSON && len(apiResponse.Message.ToolCalls) == 0 && !json.Valid([]byte(strings.TrimSpace(apiResponse.Message.Content))) {
+		return "", responseStats{}, fmt.Errorf("response is not valid JSON although the agent forces JSON output")
+	}
+
+	stats := responseStats{
+		EvalCount:     apiResponse.EvalCount,
+		EvalDuration:  time.Duration(apiResponse.EvalDuration),
+		TotalDuration: time.Duration(apiResponse.TotalDuration),
 	}
 
 	var fullResponse strings.Builder
-	fullResponse.WriteString(fmt.Sprintf("Response from %s:\n\n", agent.Role))
+	fullResponse.WriteString(responseHeader(agent.Role))
+	if agent.fallbackFrom != "" {
+		fmt.Fprintf(&fullResponse, "_Ran on fallback model %s: %s is not installed._\n\n", agent.ModelVersion, agent.fallbackFrom)
+	}
+	fullResponse.WriteString(imageWarning)
+	fullResponse.WriteString(overflowWarning)
 
-	if hasCodeChecker && hasCode {
+	if reviewsGoCode(agent, languages) {
 		if !strings.Contains(apiResponse.Message.Content, `{"name": "check_go_code"`) {
 			fullResponse.WriteString("Initial Analysis:\n")
 		}
 	}
 	fullResponse.WriteString(apiResponse.Message.Content)
 
-	if len(apiResponse.Message.ToolCalls) > 0 {
-		for _, toolCall := range apiResponse.Message.ToolCalls {
-			if toolCall.Function.Name == "check_go_code" {
-				toolCallJSON := map[string]interface{}{
-					"name":       toolCall.Function.Name,
-					"parameters": json.RawMessage(toolCall.Function.Arguments),
-				}
-
-				toolCallData, err := json.Marshal(toolCallJSON)
-				if err != nil {
-					return "", fmt.Errorf("failed to marshal tool call: %w", err)
-				}
-
-				code, err := parseToolCall(toolCallData)
-				if err != nil {
-					return "", fmt.Errorf("failed to parse tool call: %w", err)
-				}
-
-				lintResult, err := executeGolangciLint(code, agent.Role, m)
-				if err != nil {
-					analysisMessages := append(messages,
-						map[string]string{
-							"role":    "assistant",
-							"content": apiResponse.Message.Content,
-						},
-						map[string]string{
-							"role":    "user",
-							"content": fmt.Sprintf("The code checking tool found some issues:\n\n%s\n\nPlease analyze these results and provide specific recommendations.", lintResult),
-						},
-					)
-
-					analysisPayload := map[string]interface{}{
-						"model":    agent.ModelVersion,
-						"messages": analysisMessages,
-						"stream":   false,
-					}
-
-					analysisBody, err := json.Marshal(analysisPayload)
-					if err != nil {
-						return "", fmt.Errorf("failed to marshal analysis request: %w", err)
-					}
-
-					analysisResp, err := http.Post(ollamaAPIURL+"/chat", "application/json", bytes.NewBuffer(analysisBody))
-					if err != nil {
-						return "", fmt.Errorf("failed to get lint analysis: %w", err)
-					}
-					defer analysisResp.Body.Close()
-
-					var analysisResponse struct {
-						Message struct {
-							Content string `json:"content"`
-						} `json:"message"`
-					}
-
-					if err := json.NewDecoder(analysisResp.Body).Decode(&analysisResponse); err != nil {
-						return "", fmt.Errorf("failed to decode analysis response: %w", err)
-					}
-
-					fullResponse.WriteString("\n\nLint Results and Analysis:\n")
-					fullResponse.WriteString(lintResult)
-					fullResponse.WriteString("\n\nRecommendations:\n")
-					fullResponse.WriteString(analysisResponse.Message.Content)
-				} else {
-					fullResponse.WriteString("\n\nCode Check Results:\n")
-					fullResponse.WriteString(lintResult)
-				}
+	for _, toolCall := range apiResponse.Message.ToolCalls {
+		tool, ok := req.tools.lookup(toolCall.Function.Name)
+		if !ok || tool.Executor == nil {
+			fullResponse.WriteString(fmt.Sprintf("\n\nUnknown tool requested: %s", toolCall.Function.Name))
+			continue
+		}
+
+		call, err := parseToolCall(toolCall.Function.Name, toolCall.Function.Arguments)
+		if err != nil {
+			return "", responseStats{}, fmt.Errorf("failed to parse tool call: %w", err)
+		}
+
+		startedAt := time.Now()
+		toolResult, err := tool.Executor(call.Parameters)
+
+		usage := ToolUsage{
+			Timestamp: startedAt,
+			AgentRole: agent.Role,
+			ToolName:  tool.Name,
+			Output:    toolResult,
+			Success:   err == nil,
+		}
+		if inputData, marshalErr := json.Marshal(call.Parameters); marshalErr == nil {
+			usage.Input = string(inputData)
+		}
+		if err != nil {
+			usage.ErrorMessage = err.Error()
+		}
+		if req.recordUsage != nil {
+			req.recordUsage(usage)
+		}
+
+		if err != nil {
+			if toolResult == "" {
+				toolResult = err.Error()
+			}
+
+			analysisMessages := append(messages,
+				map[string]string{
+					"role":    "assistant",
+					"content": apiResponse.Message.Content,
+				},
+				map[string]string{
+					"role":    "user",
+					"content": fmt.Sprintf("The %s tool found some issues:\n\n%s\n\nPlease analyze these results and provide specific recommendations.", tool.Name, trimToolOutput(toolResult, req.toolOutputMax)),
+				},
+			)
+
+			analysisPayload := map[string]interface{}{
+				"model":    agent.ModelVersion,
+				"messages": toAPIMessages(analysisMessages),
+				"stream":   false,
 			}
+
+			analysisBody, err := json.Marshal(analysisPayload)
+			if err != nil {
+				return "", responseStats{}, fmt.Errorf("failed to marshal analysis request: %w", err)
+			}
+
+			analysisCtx, cancelAnalysis := agent.requestContext()
+			defer cancelAnalysis()
+
+			logAPIRequest(ollamaAPIURL+"/chat", analysisBody)
+			analysisResp, err := postJSONWithContext(analysisCtx, ollamaAPIURL+"/chat", analysisBody)
+			if err != nil {
+				return "", responseStats{}, agent.timeoutError(analysisCtx, fmt.Errorf("failed to get tool result analysis: %w", err))
+			}
+			defer analysisResp.Body.Close()
+			logAPIResponse(ollamaAPIURL+"/chat", analysisResp)
+
+			var analysisResponse struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+				TotalDuration int64 `json:"total_duration"`
+				EvalCount     int   `json:"eval_count"`
+				EvalDuration  int64 `json:"eval_duration"`
+			}
+
+			if err := json.NewDecoder(analysisResp.Body).Decode(&analysisResponse); err != nil {
+				return "", responseStats{}, agent.timeoutError(analysisCtx, fmt.Errorf("failed to decode analysis response: %w", err))
+			}
+
+			stats.EvalCount += analysisResponse.EvalCount
+			stats.EvalDuration += time.Duration(analysisResponse.EvalDuration)
+			stats.TotalDuration += time.Duration(analysisResponse.TotalDuration)
+
+			fullResponse.WriteString(fmt.Sprintf("\n\n%s Results and Analysis:\n", tool.Name))
+			fullResponse.WriteString(toolResult)
+			fullResponse.WriteString("\n\nRecommendations:\n")
+			fullResponse.WriteString(analysisResponse.Message.Content)
+		} else {
+			fullResponse.WriteString(fmt.Sprintf("\n\n%s Results:\n", tool.Name))
+			fullResponse.WriteString(toolResult)
 		}
 	}
 
-	return fullResponse.String(), nil
+	return fullResponse.String(), stats, nil
 }
 
-func fetchModels() ([]OllamaModel, error) {
-	apiURL := ollamaAPIURL + "/tags"
+// visionModelPatterns are substrings of model names known to accept images.
+// More can be added through the vision_models config entry.
+var visionModelPatterns = []string{
+	"llava",
+	"bakllava",
+	"llama3.2-vision",
+	"moondream",
+	"minicpm-v",
+	"qwen2.5vl",
+	"granite3.2-vision",
+	"gemma3",
+}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, err
+func isVisionModel(modelVersion string, extraPatterns []string) bool {
+	name := strings.ToLower(modelVersion)
+	for _, pattern := range append(visionModelPatterns, extraPatterns...) {
+		if pattern != "" && strings.Contains(name, strings.ToLower(pattern)) {
+			return true
+		}
 	}
+	return false
+}
+
+func hasImages(messages []map[string]string) bool {
+	for _, msg := range messages {
+		if msg["images"] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func stripImages(messages []map[string]string) []map[string]string {
+	stripped := make([]map[string]string, 0, len(messages))
+	for _, msg := range messages {
+		if msg["images"] == "" {
+			stripped = append(stripped, msg)
+			continue
+		}
+		copied := make(map[string]string, len(msg))
+		for key, value := range msg {
+			if key != "images" {
+				copied[key] = value
+			}
+		}
+		stripped = append(stripped, copied)
+	}
+	return stripped
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func fetchModels() ([]OllamaModel, error) {
+	apiURL := ollamaAPIURL + "/tags"
+
+	resp, err := doWithRetry(httpClient, func() (*http.Request, error) {
+		return http.NewRequest("GET", apiURL, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error: %v", resp.Status)
+		return nil, parseOllamaError(resp)
 	}
 
 	var response struct {
@@ -307,6 +702,73 @@ func fetchModels() ([]OllamaModel, error) {
 	return response.Models, nil
 }
 
+// showModel fetches the license, template, parameters and model metadata for
+// an installed model from Ollama's /show endpoint.
+func showModel(modelName string) (*ModelInfo, error) {
+	requestBody, err := json.Marshal(map[string]string{
+		"model": modelName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doWithRetry(httpClient, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", ollamaAPIURL+"/show", bytes.NewReader(requestBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseOllamaError(resp)
+	}
+
+	var info ModelInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// embed returns the embedding of text from modelName, which must be an
+// embedding model such as nomic-embed-text.
+func embed(modelName string, text string) ([]float64, error) {
+	requestBody, err := json.Marshal(map[string]string{
+		"model":  modelName,
+		"prompt": text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := postJSONWithRetry(ollamaAPIURL+"/embeddings", requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseOllamaError(resp)
+	}
+
+	var response struct {
+		Embedding []float64 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+	if len(response.Embedding) == 0 {
+		return nil, fmt.Errorf("%s returned no embedding; is it an embedding model?", modelName)
+	}
+	return response.Embedding, nil
+}
+
 func deleteModel(modelName string) error {
 	apiURL := ollamaAPIURL + "/delete"
 
@@ -323,34 +785,92 @@ func deleteModel(modelName string) error {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("error deleting model: %v", resp.Status)
+		return fmt.Errorf("error deleting model: %w", parseOllamaError(resp))
 	}
 	return nil
 }
 
+// createModel builds modelName from the Modelfile at modelfilePath, reporting
+// each streamed status update to onProgress like downloadModel does.
+func createModel(ctx context.Context, modelName string, modelfilePath string, onProgress func(PullResponse)) error {
+	modelfile, err := os.ReadFile(modelfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read Modelfile: %w", err)
+	}
+
+	requestBody, err := json.Marshal(map[string]string{
+		"model":     modelName,
+		"modelfile": string(modelfile),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", ollamaAPIURL+"/create", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := streamingHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return parseOllamaError(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var createResp struct {
+			PullResponse
+			Error string `json:"error,omitempty"`
+		}
+		if err := decoder.Decode(&createResp); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		if createResp.Error != "" {
+			return fmt.Errorf("create error: %s", createResp.Error)
+		}
+
+		if onProgress != nil {
+			onProgress(createResp.PullResponse)
+		}
+
+		if createResp.Status == "success" {
+			break
+		}
+	}
+
+	return nil
+}
+
 func requestOllama(messages []map[string]string, agent Agent) (string, error) {
 	apiURL := ollamaAPIURL + "/chat"
 
-	numCtx, err := strconv.Atoi(agent.Tokens)
-	if err != nil || numCtx <= 0 {
-		numCtx = 16384
-	}
+	numCtx := agent.contextWindow()
 
 	options := map[string]interface{}{
 		"num_ctx": numCtx,
 	}
+	agent.applySamplingOptions(options)
 
 	requestBody, err := json.Marshal(map[string]interface{}{
 		"model":    agent.ModelVersion,
-		"messages": messages,
+		"messages": toAPIMessages(messages),
 		"stream":   false,
 		"options":  options,
 	})
@@ -358,27 +878,24 @@ func requestOllama(messages []map[string]string, agent Agent) (string, error) {
 		return "", fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+	ctx, cancel := agent.requestContext()
+	defer cancel()
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	logAPIRequest(apiURL, requestBody)
+	resp, err := postJSONWithContext(ctx, apiURL, requestBody)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return "", agent.timeoutError(ctx, fmt.Errorf("request failed: %w", err))
 	}
 	defer resp.Body.Close()
+	logAPIResponse(apiURL, resp)
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("error: %v", resp.Status)
+		return "", parseOllamaError(resp)
 	}
 
 	var rawResponse map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&rawResponse); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return "", agent.timeoutError(ctx, fmt.Errorf("failed to decode response: %w", err))
 	}
 
 	if message, ok := rawResponse["message"].(map[string]interface{}); ok {
@@ -2,18 +2,23 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 func scrapeOllamaLibrary() ([]AvailableModel, error) {
 	url := "https://ollama.com/library"
-	response, err := http.Get(url)
+	response, err := httpClient.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve the page: %v", err)
 	}
@@ -37,6 +42,39 @@ func scrapeOllamaLibrary() ([]AvailableModel, error) {
 	return models, nil
 }
 
+type libraryCache struct {
+	FetchedAt time.Time        `json:"fetched_at"`
+	Models    []AvailableModel `json:"models"`
+}
+
+func loadLibraryCache(path string) (libraryCache, error) {
+	var cache libraryCache
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache, fmt.Errorf("failed to read library cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return cache, fmt.Errorf("failed to unmarshal library cache: %w", err)
+	}
+
+	return cache, nil
+}
+
+func saveLibraryCache(path string, models []AvailableModel) error {
+	data, err := json.MarshalIndent(libraryCache{FetchedAt: time.Now(), Models: models}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal library cache: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write library cache: %w", err)
+	}
+
+	return nil
+}
+
 func parseContent(doc *goquery.Document) []AvailableModel {
 	var models []AvailableModel
 	liElements := doc.Find("li")
@@ -76,7 +114,71 @@ func parseContent(doc *goquery.Document) []AvailableModel {
 	return models
 }
 
-func downloadModel(modelName string) error {
+// scrapeModelTags lists every tag published for a model on its library tags
+// page, e.g. "3b", "3b-instruct-q4_K_M".
+func scrapeModelTags(name string) ([]string, error) {
+	response, err := httpClient.Get("https://ollama.com/library/" + name + "/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the tags page: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to retrieve the tags page. Status code: %d", response.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	return parseModelTags(doc, name), nil
+}
+
+// parseModelTags collects the tags from links of the form
+// /library/<name>:<tag>.
+func parseModelTags(doc *goquery.Document, name string) []string {
+	prefix := "/library/" + name + ":"
+	seen := make(map[string]bool)
+	var tags []string
+
+	doc.Find("a[href]").Each(func(i int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		tag, ok := strings.CutPrefix(href, prefix)
+		if !ok || tag == "" || seen[tag] {
+			return
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	})
+
+	return tags
+}
+
+var quantizationPattern = regexp.MustCompile(`(?i)^(q\d\w*|iq\d\w*|fp16|f16|bf16|fp32|f32)$`)
+
+// quantizationTags returns the tags that are quantization variants of size,
+// such as "3b-instruct-q4_K_M" for "3b", in a stable order.
+func quantizationTags(tags []string, size string) []string {
+	var variants []string
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, size+"-") {
+			continue
+		}
+		parts := strings.Split(tag, "-")
+		if quantizationPattern.MatchString(parts[len(parts)-1]) {
+			variants = append(variants, tag)
+		}
+	}
+	sort.Strings(variants)
+	return variants
+}
+
+// downloadModel pulls modelName, reporting each streamed status update to
+// onProgress. Ollama has no endpoint to cancel a pull; cancelling ctx closes
+// the connection, which stops it and keeps the layers fetched so far for the
+// next attempt to resume from.
+func downloadModel(ctx context.Context, modelName string, onProgress func(PullResponse)) error {
 	requestBody, err := json.Marshal(map[string]string{
 		"name": modelName,
 	})
@@ -84,14 +186,13 @@ func downloadModel(modelName string) error {
 		return fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", ollamaAPIURL+"/pull", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", ollamaAPIURL+"/pull", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := streamingHTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -111,6 +212,10 @@ func downloadModel(modelName string) error {
 			return fmt.Errorf("pull error: %s", pullResp.Status)
 		}
 
+		if onProgress != nil {
+			onProgress(pullResp)
+		}
+
 		if pullResp.Status == "success" {
 			break
 		}
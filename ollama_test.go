@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildSystemPrompt(t *testing.T) {
+	goChecker := []Tool{{Name: checkGoCodeTool.Name}}
+	goInput := "Please review:\n```go\npackage main\n```"
+
+	tests := []struct {
+		name         string
+		agent        Agent
+		context      string
+		input        string
+		want         string
+		wantPrefix   string
+		wantContains string
+	}{
+		{
+			name:    "context placeholder",
+			agent:   Agent{SystemPrompt: "Answer using this:\n{context}\nBe brief."},
+			context: "the docs",
+			input:   "hello",
+			want:    "Answer using this:\nthe docs\nBe brief.",
+		},
+		{
+			name:    "context appended without placeholder",
+			agent:   Agent{SystemPrompt: "You are helpful."},
+			context: "the docs",
+			input:   "hello",
+			want:    "You are helpful.\n\nContext:\nthe docs",
+		},
+		{
+			name:  "empty context removes placeholder",
+			agent: Agent{SystemPrompt: "You are helpful.\n\n{context}"},
+			input: "hello",
+			want:  "You are helpful.",
+		},
+		{
+			name:  "empty prompt falls back to default",
+			agent: Agent{},
+			input: "hello",
+			want:  defaultSystemPrompt,
+		},
+		{
+			name:       "code checker overrides prompt for go code",
+			agent:      Agent{SystemPrompt: "You are helpful.", Tools: goChecker},
+			input:      goInput,
+			wantPrefix: "You are a code review assistant.",
+		},
+		{
+			name:         "code checker override keeps context",
+			agent:        Agent{SystemPrompt: "{context}", Tools: goChecker},
+			context:      "style guide",
+			input:        goInput,
+			wantPrefix:   "You are a code review assistant.",
+			wantContains: "\n\nContext: style guide",
+		},
+		{
+			name:  "code checker without go code",
+			agent: Agent{SystemPrompt: "You are helpful.", Tools: goChecker},
+			input: "```python\nprint(1)\n```",
+			want:  "You are helpful.",
+		},
+		{
+			name:  "go code without code checker",
+			agent: Agent{SystemPrompt: "You are helpful."},
+			input: goInput,
+			want:  "You are helpful.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSystemPrompt(tt.agent, tt.context, tt.input)
+			if tt.wantPrefix == "" && got != tt.want {
+				t.Errorf("buildSystemPrompt() = %q, want %q", got, tt.want)
+			}
+			if tt.wantPrefix != "" && !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("buildSystemPrompt() = %q, want prefix %q", got, tt.wantPrefix)
+			}
+			if !strings.Contains(got, tt.wantContains) {
+				t.Errorf("buildSystemPrompt() = %q, want it to contain %q", got, tt.wantContains)
+			}
+		})
+	}
+}
+
+func TestBuildMessages(t *testing.T) {
+	contextPath := filepath.Join(t.TempDir(), "context.txt")
+	if err := os.WriteFile(contextPath, []byte("project notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	history := []map[string]string{
+		{"role": "user", "content": "earlier question"},
+		{"role": "assistant", "content": "earlier answer", "agent": "Helper"},
+	}
+
+	tests := []struct {
+		name    string
+		agent   Agent
+		want    []map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "without conversation",
+			agent: Agent{SystemPrompt: "Be brief."},
+			want: []map[string]string{
+				{"role": "system", "content": "Be brief."},
+				{"role": "user", "content": "new question"},
+			},
+		},
+		{
+			name:  "with conversation",
+			agent: Agent{SystemPrompt: "Be brief.", UseConversation: true},
+			want: []map[string]string{
+				{"role": "system", "content": "Be brief."},
+				history[0],
+				history[1],
+				{"role": "user", "content": "new question"},
+			},
+		},
+		{
+			name:  "context file substituted",
+			agent: Agent{SystemPrompt: "Notes: {context}", UseContext: true, ContextFilePath: contextPath},
+			want: []map[string]string{
+				{"role": "system", "content": "Notes: project notes"},
+				{"role": "user", "content": "new question"},
+			},
+		},
+		{
+			name:  "context ignored when disabled",
+			agent: Agent{SystemPrompt: "Notes: {context}", ContextFilePath: contextPath},
+			want: []map[string]string{
+				{"role": "system", "content": "Notes:"},
+				{"role": "user", "content": "new question"},
+			},
+		},
+		{
+			name:    "missing context file",
+			agent:   Agent{Role: "Reader", UseContext: true, ContextFilePath: filepath.Join(t.TempDir(), "missing.txt")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildMessages(tt.agent, "new question", history)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildMessages() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildMessages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// promptPreview renders the messages each enabled agent would receive for
+// input, following the same chaining as sendChatMessage. Responses that don't
+// exist yet are shown as placeholders.
+func (m *model) promptPreview(input string) (string, error) {
+	agents := m.enabledAgents()
+	if len(agents) == 0 {
+		return "", fmt.Errorf("no enabled agents configured")
+	}
+
+	// sendChatMessage records the user message before running the chain, so
+	// agents that use the conversation see it in their history too
+	history := make([]map[string]string, len(m.conversationHistory), len(m.conversationHistory)+1)
+	copy(history, m.conversationHistory)
+	history = append(history, map[string]string{
+		"role":    "user",
+		"content": input,
+	})
+
+	// attached files are only part of what the agents are sent
+	message, _, err := withAttachedFiles(input, m.pendingFiles)
+	if err != nil {
+		return "", err
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(activeTheme.Accent).Bold(true)
+	roleStyle := lipgloss.NewStyle().Foreground(activeTheme.Muted)
+
+	var b strings.Builder
+	currentInput := message
+	for i := 0; i < len(agents); {
+		end := i + 1
+		if agents[i].Parallel {
+			for end < len(agents) && agents[end].Parallel {
+				end++
+			}
+		}
+
+		agentInput := currentInput
+		if agents[i].Parallel || !agents[i].UseChainInput {
+			agentInput = message
+		}
+
+		var roles []string
+		for j := i; j < end; j++ {
+			agent := inheritSystemPrompt(agents[j], m.projectSystemPrompt())
+			roles = append(roles, agent.Role)
+
+			messages, err := buildMessages(agent, agentInput, history)
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(headerStyle.Render(fmt.Sprintf("── Agent %d/%d: %s (%s) ──", j+1, len(agents), agent.Role, agent.ModelVersion)))
+			b.WriteString("\n\n")
+			for _, msg := range messages {
+				b.WriteString(roleStyle.Render("[" + msg["role"] + "]"))
+				b.WriteString("\n")
+				b.WriteString(msg["content"])
+				b.WriteString("\n\n")
+			}
+			if len(m.pendingImages) > 0 {
+				b.WriteString(roleStyle.Render(fmt.Sprintf("(%d image(s) attached to the user message)", len(m.pendingImages))))
+				b.WriteString("\n\n")
+			}
+		}
+
+		if len(roles) == 1 {
+			currentInput = fmt.Sprintf("<response from %s>", roles[0])
+		} else {
+			currentInput = fmt.Sprintf("<combined responses from %s>", strings.Join(roles, ", "))
+		}
+		i = end
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// showPromptPreview opens the preview for the message being composed without
+// sending it.
+func (m *model) showPromptPreview() tea.Cmd {
+	input := strings.TrimSpace(m.textarea.Value())
+	if input == "" {
+		return func() tea.Msg { return notifyMsg("Type a message first to preview the prompt.") }
+	}
+
+	content, err := m.promptPreview(input)
+	if err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to build prompt preview: %w", err)) }
+	}
+
+	m.previewViewport.Width = m.width
+	m.previewViewport.Height = m.height - 2
+	m.previewViewport.SetContent(lipgloss.NewStyle().Width(m.width).Render(content))
+	m.previewViewport.GotoTop()
+	m.viewMode = PromptPreviewView
+	m.textarea.Blur()
+	return nil
+}
+
+func (m model) promptPreviewView() string {
+	hint := lipgloss.NewStyle().Foreground(activeTheme.Muted).
+		Render("Prompt preview — nothing has been sent. j/k to scroll, esc to go back.")
+	return hint + "\n\n" + m.previewViewport.View()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/huh"
+)
+
+func saveProjects(m *model) error {
+	data, err := json.MarshalIndent(m.projects, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal projects: %w", err)
+	}
+
+	if err := writeFileAtomic(m.projectsFilePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write projects to file: %w", err)
+	}
+	return nil
+}
+
+func loadProjects(m *model) error {
+	m.projects = map[string]ProjectSettings{}
+	if _, err := os.Stat(m.projectsFilePath); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.projectsFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read projects file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &m.projects); err != nil {
+		return fmt.Errorf("failed to unmarshal projects: %w", err)
+	}
+	return nil
+}
+
+// projectSystemPrompt returns the system prompt configured for the current
+// chat's project, or "" for temporary chats and projects without one.
+func (m *model) projectSystemPrompt() string {
+	if m.selectedChat == nil || m.selectedChat.ProjectName == "" {
+		return ""
+	}
+	return m.projects[m.selectedChat.ProjectName].SystemPrompt
+}
+
+// inheritSystemPrompt resolves which system prompt an agent runs with: its
+// own if set, otherwise the project's, otherwise defaultSystemPrompt (applied
+// later by buildSystemPrompt).
+func inheritSystemPrompt(agent Agent, projectPrompt string) Agent {
+	if strings.TrimSpace(agent.SystemPrompt) == "" && projectPrompt != "" {
+		agent.SystemPrompt = projectPrompt
+	}
+	return agent
+}
+
+// hoveredProject returns the project of the chat or header under the cursor
+// in the chat list.
+func (m *model) hoveredProject() (string, bool) {
+	switch item := m.chatList.SelectedItem().(type) {
+	case projectHeaderItem:
+		return item.project, item.project != ""
+	case chatItem:
+		return item.chat.ProjectName, item.chat.ID != "" && item.chat.ProjectName != ""
+	}
+	return "", false
+}
+
+// editProjectSettings opens the settings form for the hovered project.
+func (m *model) editProjectSettings() bool {
+	if m.chatList.FilterState() == list.Filtering {
+		return false
+	}
+	project, ok := m.hoveredProject()
+	if !ok {
+		return false
+	}
+
+	m.editingProject = project
+	m.projectPromptDraft = m.projects[project].SystemPrompt
+	m.projectForm = createProjectForm(project, &m.projectPromptDraft)
+	m.viewMode = ProjectFormView
+	m.formActive = true
+	return true
+}
+
+// saveProjectSettings stores the submitted form, dropping projects that no
+// longer override anything.
+func (m *model) saveProjectSettings() error {
+	settings := m.projects[m.editingProject]
+	settings.SystemPrompt = strings.TrimSpace(m.projectPromptDraft)
+	if settings.SystemPrompt == "" {
+		delete(m.projects, m.editingProject)
+	} else {
+		m.projects[m.editingProject] = settings
+	}
+	m.editingProject = ""
+	m.projectPromptDraft = ""
+	return saveProjects(m)
+}
+
+func createProjectForm(project string, systemPrompt *string) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewText().
+				Title(fmt.Sprintf("System Prompt for %s", project)).
+				Description("Used by agents in this project's chats that have no system prompt of their own. Leave empty to use the default.").
+				Value(systemPrompt),
+		),
+	).WithShowHelp(true)
+}
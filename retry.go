@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const retryBaseDelay = 500 * time.Millisecond
+
+var (
+	// maxRequestAttempts is overridden from the chat config at startup.
+	maxRequestAttempts = 3
+
+	// onRetry is called before each retry so the UI can show progress.
+	onRetry func(attempt int, maxAttempts int, err error)
+)
+
+// doWithRetry sends the request built by newRequest, retrying with
+// exponential backoff on connection errors and 5xx responses. 4xx responses
+// are returned immediately, as are errors from a cancelled or expired request
+// context. After the last attempt the final response or error is returned
+// as-is.
+func doWithRetry(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if err != nil && (errors.Is(err, context.Canceled) || req.Context().Err() != nil) {
+			return nil, err
+		}
+		if attempt >= maxRequestAttempts {
+			return resp, err
+		}
+
+		if err == nil {
+			err = fmt.Errorf("server error: %s", resp.Status)
+			resp.Body.Close()
+		}
+
+		log.Printf("Request to %s failed (attempt %d/%d): %v", req.URL, attempt, maxRequestAttempts, err)
+		if onRetry != nil {
+			onRetry(attempt+1, maxRequestAttempts, err)
+		}
+		time.Sleep(retryBaseDelay * time.Duration(1<<(attempt-1)))
+	}
+}
+
+func postJSONWithRetry(url string, body []byte) (*http.Response, error) {
+	return postJSONWithContext(context.Background(), url, body)
+}
+
+// postJSONWithContext is postJSONWithRetry bound to ctx. When ctx carries a
+// deadline it replaces the client's fixed timeout, so an agent can be given
+// more (or less) time than the default.
+func postJSONWithContext(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	client := httpClient
+	if _, ok := ctx.Deadline(); ok {
+		client = streamingHTTPClient
+	}
+	return doWithRetry(client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func setupSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "Search conversation"
+	return ti
+}
+
+func (m *model) startSearch() tea.Cmd {
+	m.searchActive = true
+	m.searchInput.SetValue(m.searchQuery)
+	m.searchInput.CursorEnd()
+	m.textarea.Blur()
+	return m.searchInput.Focus()
+}
+
+func (m *model) clearSearch() {
+	m.searchActive = false
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchIndex = 0
+	m.searchInput.Blur()
+	m.refreshViewportContent()
+}
+
+// updateSearch handles keys while the search prompt is open. Enter runs the
+// search and esc abandons it.
+func (m *model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.searchActive = false
+		m.searchInput.Blur()
+		m.searchQuery = strings.TrimSpace(m.searchInput.Value())
+		m.searchIndex = 0
+		m.refreshViewportContent()
+		return m, nil
+	case "esc":
+		m.clearSearch()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return m, cmd
+}
+
+// jumpToMatch moves delta matches forward or back, wrapping at either end.
+func (m *model) jumpToMatch(delta int) {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+	m.searchIndex = (m.searchIndex + delta + len(m.searchMatches)) % len(m.searchMatches)
+	m.refreshViewportContent()
+}
+
+// searchMatchLines returns the line numbers of rendered content whose visible
+// text contains query, ignoring case.
+func searchMatchLines(content string, query string) []int {
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+
+	var matches []int
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(strings.ToLower(ansi.Strip(line)), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// highlightSearchMatches redraws matching lines as plain text with every hit
+// highlighted; the line holding the current match gets the accent color.
+// Styling inside ANSI sequences can't be patched reliably, so matching lines
+// lose their markdown styling while the search is active.
+func highlightSearchMatches(content string, query string, currentLine int) string {
+	if query == "" {
+		return content
+	}
+
+	matchStyle := lipgloss.NewStyle().Reverse(true)
+	currentStyle := lipgloss.NewStyle().
+		Foreground(activeTheme.Background).
+		Background(activeTheme.Accent).
+		Bold(true)
+
+	lowerQuery := strings.ToLower(query)
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		lowerPlain := strings.ToLower(plain)
+		if !strings.Contains(lowerPlain, lowerQuery) {
+			continue
+		}
+
+		style := matchStyle
+		if i == currentLine {
+			style = currentStyle
+		}
+
+		// lowercasing can change byte lengths for some scripts, in which case
+		// the offsets no longer line up and the whole line is highlighted
+		if len(lowerPlain) != len(plain) {
+			lines[i] = style.Render(plain)
+			continue
+		}
+
+		var b strings.Builder
+		rest := plain
+		lowerRest := lowerPlain
+		for {
+			idx := strings.Index(lowerRest, lowerQuery)
+			if idx < 0 {
+				b.WriteString(rest)
+				break
+			}
+			b.WriteString(rest[:idx])
+			b.WriteString(style.Render(rest[idx : idx+len(lowerQuery)]))
+			rest = rest[idx+len(lowerQuery):]
+			lowerRest = lowerRest[idx+len(lowerQuery):]
+		}
+		lines[i] = b.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m model) searchStatusLine() string {
+	if m.searchActive {
+		return m.searchInput.View()
+	}
+
+	style := lipgloss.NewStyle().Foreground(activeTheme.Muted)
+	if len(m.searchMatches) == 0 {
+		return errorStyle.Render(fmt.Sprintf("No matches for %q — esc to clear", m.searchQuery))
+	}
+	return style.Render(fmt.Sprintf("%q %d/%d — n/N to jump, esc to clear",
+		m.searchQuery, m.searchIndex+1, len(m.searchMatches)))
+}
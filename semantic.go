@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// chatEmbeddings is the sidecar file kept next to a chat for semantic
+// search, holding one vector per message keyed by messageKey. Vectors from a
+// different model can't be compared, so they are discarded on a change.
+type chatEmbeddings struct {
+	Model   string               `json:"model"`
+	Vectors map[string][]float64 `json:"vectors"`
+}
+
+// semanticResult is a message that matched a semantic search.
+type semanticResult struct {
+	chat  Chat
+	index int
+	score float64
+}
+
+func embeddingsPath(folderPath string, chatID string) string {
+	return filepath.Join(folderPath, embeddingsFolderName, chatID+".json")
+}
+
+// loadChatEmbeddings reads a chat's sidecar, starting afresh when it is
+// missing, unreadable or made with another model.
+func loadChatEmbeddings(path string, modelName string) chatEmbeddings {
+	fresh := chatEmbeddings{Model: modelName, Vectors: map[string][]float64{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fresh
+	}
+	var stored chatEmbeddings
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("Ignoring unreadable embeddings file %s: %v", path, err)
+		return fresh
+	}
+	if stored.Model != modelName || stored.Vectors == nil {
+		return fresh
+	}
+	return stored
+}
+
+func saveChatEmbeddings(path string, embeddings chatEmbeddings) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create embeddings directory: %w", err)
+	}
+	data, err := json.Marshal(embeddings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embeddings: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// messageKey identifies a message by its content, so an edited message is
+// embedded again and a deleted one drops out of the sidecar.
+func messageKey(msg map[string]string) string {
+	sum := sha256.Sum256([]byte(msg["role"] + "\x00" + msg["content"]))
+	return hex.EncodeToString(sum[:])
+}
+
+// searchableMessage reports whether a message is worth embedding: user and
+// assistant messages with content, but not failed-agent notes.
+func searchableMessage(msg map[string]string) bool {
+	role := msg["role"]
+	return (role == "user" || role == "assistant") && msg["error"] == "" && strings.TrimSpace(msg["content"]) != ""
+}
+
+// indexChat embeds the chat's messages that have no vector yet and saves the
+// sidecar when anything changed.
+func indexChat(folderPath string, chat Chat, modelName string) (chatEmbeddings, error) {
+	path := embeddingsPath(folderPath, chat.ID)
+	stored := loadChatEmbeddings(path, modelName)
+
+	current := chatEmbeddings{Model: modelName, Vectors: map[string][]float64{}}
+	changed := false
+	for _, msg := range chat.Messages {
+		if !searchableMessage(msg) {
+			continue
+		}
+		key := messageKey(msg)
+		if _, done := current.Vectors[key]; done {
+			continue
+		}
+		if vector, ok := stored.Vectors[key]; ok {
+			current.Vectors[key] = vector
+			continue
+		}
+
+		content := msg["content"]
+		// embedding models have small context windows; the start of a long
+		// message is enough to find it
+		if len(content) > maxEmbeddingBytes {
+			content = content[:maxEmbeddingBytes]
+		}
+		vector, err := embed(modelName, content)
+		if err != nil {
+			return current, fmt.Errorf("failed to embed chat '%s': %w", chat.Name, err)
+		}
+		current.Vectors[key] = vector
+		changed = true
+	}
+
+	if changed || len(current.Vectors) != len(stored.Vectors) {
+		if err := saveChatEmbeddings(path, current); err != nil {
+			log.Printf("Failed to save embeddings for chat %s: %v", chat.ID, err)
+		}
+	}
+	return current, nil
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// semanticSearchCmd ranks the messages of every saved chat by similarity to
+// query, embedding any messages that haven't been indexed yet.
+func semanticSearchCmd(folderPath string, modelName string, query string) tea.Cmd {
+	return func() tea.Msg {
+		queryVector, err := embed(modelName, query)
+		if err != nil {
+			return semanticSearchMsg{err: fmt.Errorf("failed to embed search: %w", err)}
+		}
+
+		chats, _, err := loadChats(folderPath)
+		if err != nil {
+			return semanticSearchMsg{err: err}
+		}
+
+		var results []semanticResult
+		for _, chat := range chats {
+			embeddings, err := indexChat(folderPath, chat, modelName)
+			if err != nil {
+				return semanticSearchMsg{err: err}
+			}
+			for i, msg := range chat.Messages {
+				if vector, ok := embeddings.Vectors[messageKey(msg)]; ok {
+					results = append(results, semanticResult{chat: chat, index: i, score: cosineSimilarity(queryVector, vector)})
+				}
+			}
+		}
+
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].score > results[j].score
+		})
+		if len(results) > maxSemanticResults {
+			results = results[:maxSemanticResults]
+		}
+		return semanticSearchMsg{results: results}
+	}
+}
+
+func createSemanticSearchForm(query *string) *huh.Form {
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Search Chats by Meaning").
+				Description("e.g. where I discussed goroutine leaks").
+				Value(query).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("search cannot be empty")
+					}
+					return nil
+				}),
+		),
+	).WithShowHelp(true)
+	return form
+}
+
+// embeddingModel is the model used to index chats for semantic search.
+func (m *model) embeddingModel() string {
+	if m.config.EmbeddingModel == "" {
+		return defaultEmbeddingModel
+	}
+	return m.config.EmbeddingModel
+}
+
+// openSemanticSearch asks for a query to search every saved chat with.
+func (m *model) openSemanticSearch() {
+	m.semanticQuery = ""
+	m.semanticForm = createSemanticSearchForm(&m.semanticQuery)
+	m.viewMode = SemanticSearchFormView
+	m.formActive = true
+}
+
+// startSemanticSearch shows the results view and runs the search in the
+// background.
+func (m *model) startSemanticSearch() tea.Cmd {
+	m.viewMode = SemanticSearchView
+	m.semanticSearching = true
+	m.semanticResults = nil
+	m.semanticTable.SetRows(nil)
+	m.semanticTable.Focus()
+	m.resizeActiveTable()
+	return semanticSearchCmd(m.chatsFolderPath, m.embeddingModel(), strings.TrimSpace(m.semanticQuery))
+}
+
+func (m *model) populateSemanticTable() {
+	rows := make([]table.Row, 0, len(m.semanticResults))
+	for _, result := range m.semanticResults {
+		msg := result.chat.Messages[result.index]
+		rows = append(rows, table.Row{
+			fmt.Sprintf("%.2f", result.score),
+			result.chat.Name,
+			msg["role"],
+			strings.Join(strings.Fields(msg["content"]), " "),
+		})
+	}
+	m.semanticTable.SetRows(rows)
+	m.semanticTable.GotoTop()
+}
+
+// openSemanticResult opens the chat of the highlighted result with its
+// message selected.
+func (m *model) openSemanticResult() {
+	cursor := m.semanticTable.Cursor()
+	if cursor < 0 || cursor >= len(m.semanticResults) {
+		return
+	}
+	result := m.semanticResults[cursor]
+	chat := result.chat
+
+	m.semanticTable.Blur()
+	m.handleChatSelection(&chat)
+	m.viewMode = MessageSelectView
+	m.selectedMessage = result.index
+	m.textarea.Blur()
+	m.updateViewport()
+}
+
+func (m model) semanticSearchView() string {
+	header := fmt.Sprintf("Semantic Search: %q", strings.TrimSpace(m.semanticQuery))
+	if m.semanticSearching {
+		return fmt.Sprintf("%s\n\n  %s Indexing chats with %s and searching...\n\nPress esc to go back.", header, m.spinner.View(), m.embeddingModel())
+	}
+	if len(m.semanticResults) == 0 {
+		return header + "\n\nNo matching messages.\n\nPress esc to go back."
+	}
+	return fmt.Sprintf("%s\n\n%s\n\nPress enter to open the message, esc to go back.", header, m.semanticTable.View())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// statusName labels the chat modes in the status bar.
+func (v viewMode) statusName() string {
+	switch v {
+	case InsertView:
+		return "INSERT"
+	case MessageSelectView:
+		return "SELECT"
+	}
+	return "CHAT"
+}
+
+// statusBar renders the line under the chat views: the mode, the open chat
+// (flagging temporary chats, which aren't saved), how many agents are in the
+// chain and the first agent's model, cut short when the window is too
+// narrow.
+func (m model) statusBar() string {
+	segment := lipgloss.NewStyle().Padding(0, 1)
+	modeStyle := segment.
+		Bold(true).
+		Foreground(activeTheme.Background).
+		Background(activeTheme.Accent)
+	chatStyle := segment.
+		Foreground(activeTheme.Foreground).
+		Background(activeTheme.Muted)
+	temporaryStyle := segment.
+		Bold(true).
+		Foreground(activeTheme.Background).
+		Background(activeTheme.Error)
+	infoStyle := segment.Foreground(activeTheme.Muted)
+
+	name := "Temporary Chat"
+	if m.selectedChat != nil {
+		name = m.selectedChat.Name
+		if project := m.selectedChat.ProjectName; project != "" && !m.isTemporaryChat() {
+			name = fmt.Sprintf("%s / %s", project, name)
+		}
+	}
+
+	segments := []string{
+		modeStyle.Render(m.viewMode.statusName()),
+		chatStyle.Render(name),
+	}
+	if m.isTemporaryChat() {
+		segments = append(segments, temporaryStyle.Render("unsaved — temporary"))
+	}
+
+	agents := m.enabledAgents()
+	count := fmt.Sprintf("%d agents", len(agents))
+	if len(agents) == 1 {
+		count = "1 agent"
+	}
+	segments = append(segments, infoStyle.Render(count))
+	if len(agents) > 0 {
+		model := agents[0].ModelVersion
+		if model == "" {
+			model = "no model"
+		}
+		segments = append(segments, infoStyle.Render(model))
+	}
+
+	bar := lipgloss.JoinHorizontal(lipgloss.Top, segments...)
+	if m.width > 0 && lipgloss.Width(bar) > m.width {
+		return ansi.Truncate(bar, m.width, "…")
+	}
+	return bar
+}
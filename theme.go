@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Theme struct {
+	Name       string
+	Foreground lipgloss.Color
+	Background lipgloss.Color
+	Accent     lipgloss.Color
+	Error      lipgloss.Color
+	Muted      lipgloss.Color
+}
+
+var themes = []Theme{
+	{
+		Name:       "dark",
+		Foreground: lipgloss.Color("#FFFFFF"),
+		Background: lipgloss.Color("#000000"),
+		Accent:     lipgloss.Color("#00FF00"),
+		Error:      lipgloss.Color("#FF0000"),
+		Muted:      lipgloss.Color("#666666"),
+	},
+	{
+		Name:       "light",
+		Foreground: lipgloss.Color("#1A1A1A"),
+		Background: lipgloss.Color("#FFFFFF"),
+		Accent:     lipgloss.Color("#007A33"),
+		Error:      lipgloss.Color("#C00000"),
+		Muted:      lipgloss.Color("#8A8A8A"),
+	},
+	{
+		Name:       "high-contrast",
+		Foreground: lipgloss.Color("#FFFFFF"),
+		Background: lipgloss.Color("#000000"),
+		Accent:     lipgloss.Color("#FFFF00"),
+		Error:      lipgloss.Color("#FF00FF"),
+		Muted:      lipgloss.Color("#FFFFFF"),
+	},
+}
+
+var activeTheme = themes[0]
+
+func themeByName(name string) (Theme, bool) {
+	for _, theme := range themes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+func nextTheme(current string) Theme {
+	for i, theme := range themes {
+		if theme.Name == current {
+			return themes[(i+1)%len(themes)]
+		}
+	}
+	return themes[0]
+}
+
+// setActiveTheme swaps the package-level colors used while rendering.
+func setActiveTheme(theme Theme) {
+	activeTheme = theme
+	runningIndicatorColor = theme.Accent
+	stoppedIndicatorColor = theme.Error
+	errorStyle = lipgloss.NewStyle().
+		Foreground(theme.Error).
+		Bold(true)
+}
+
+func tableStyles() table.Styles {
+	styles := table.DefaultStyles()
+	styles.Header = lipgloss.NewStyle().Bold(true).Foreground(activeTheme.Foreground)
+	styles.Selected = lipgloss.NewStyle().Foreground(activeTheme.Background).Background(activeTheme.Accent)
+	return styles
+}
+
+func headerStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(activeTheme.Foreground).
+		Background(activeTheme.Muted).
+		Padding(0, 1)
+}
+
+// applyTheme re-styles every component that caches its styles.
+func (m *model) applyTheme(theme Theme) {
+	setActiveTheme(theme)
+
+	styles := tableStyles()
+	m.modelTable.SetStyles(styles)
+	m.availableTable.SetStyles(styles)
+	m.parameterSizesTable.SetStyles(styles)
+	m.agentsTable.SetStyles(styles)
+	m.toolUsageTable.SetStyles(styles)
+	m.semanticTable.SetStyles(styles)
+
+	m.spinner.Style = lipgloss.NewStyle().Foreground(theme.Accent)
+	m.chatList.SetDelegate(newChatDelegate(m.markedChats))
+	m.chatList.Styles.Title = headerStyle()
+	m.updateTextareaIndicatorColor()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// showToast displays text at the bottom of the current view until
+// toastDuration passes or another toast replaces it.
+func (m *model) showToast(text string) tea.Cmd {
+	m.toast = text
+	m.toastID++
+	id := m.toastID
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg(id)
+	})
+}
+
+func (m model) View() string {
+	view := m.view()
+	if m.toast == "" || m.errorMessage != "" {
+		return view
+	}
+
+	toast := lipgloss.NewStyle().
+		Foreground(activeTheme.Background).
+		Background(activeTheme.Accent).
+		Padding(0, 1).
+		Render(m.toast)
+
+	// take over the last line when the view already fills the window, so the
+	// toast doesn't push the top of it off screen
+	lines := strings.Split(view, "\n")
+	if m.height > 0 && len(lines) >= m.height {
+		lines = lines[:m.height-1]
+		return strings.Join(lines, "\n") + "\n" + toast
+	}
+	return view + "\n" + toast
+}
@@ -2,14 +2,73 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/format"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
+// ToolExecutor runs a tool with the arguments from a model's tool call and
+// returns the output that is shown to the user and fed back to the model.
+type ToolExecutor func(args map[string]string) (string, error)
+
+type toolRegistry map[string]Tool
+
+func newToolRegistry() toolRegistry {
+	registry := toolRegistry{}
+	registry.register(checkGoCodeTool)
+	registry.register(checkPythonCodeTool)
+	registry.register(checkJavaScriptCodeTool)
+	return registry
+}
+
+func (r toolRegistry) register(tool Tool) {
+	r[tool.Name] = tool
+}
+
+func (r toolRegistry) lookup(name string) (Tool, bool) {
+	tool, ok := r[name]
+	return tool, ok
+}
+
+// list returns the registered tools sorted by name so forms render them in a
+// stable order.
+func (r toolRegistry) list() []Tool {
+	tools := make([]Tool, 0, len(r))
+	for _, tool := range r {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	return tools
+}
+
+// payload converts the tool into the function definition format expected by
+// the Ollama chat API.
+func (t Tool) payload() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":        t.Name,
+			"description": t.Description,
+			"parameters":  t.Parameters,
+		},
+	}
+}
+
 var checkGoCodeTool = Tool{
 	Name:        "check_go_code",
 	Description: "Check Go code for errors and style issues using golint.",
@@ -23,6 +82,444 @@ var checkGoCodeTool = Tool{
 		},
 		"required": []string{"code"},
 	},
+	Executor: func(args map[string]string) (string, error) {
+		code := args["code"]
+		if code == "" {
+			return "", fmt.Errorf("code parameter not found in tool call")
+		}
+
+		code = strings.ReplaceAll(code, "\\n", "\n")
+		code = strings.ReplaceAll(code, "\\\"", "\"")
+		code = strings.Trim(code, "\"\"\"")
+
+		return executeGolangciLint(code)
+	},
+}
+
+// codeCheckers maps a code block language to the tool that checks it. A
+// checker is only offered to a model when the input contains its language.
+var codeCheckers = map[string]string{
+	"go":         checkGoCodeTool.Name,
+	"python":     checkPythonCodeTool.Name,
+	"javascript": checkJavaScriptCodeTool.Name,
+}
+
+// checkerLanguage reports which language a checker tool handles.
+func checkerLanguage(toolName string) (string, bool) {
+	for language, name := range codeCheckers {
+		if name == toolName {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// linterCommand is an external linter invoked with the snippet path appended
+// to its arguments.
+type linterCommand struct {
+	name string
+	args []string
+	// argsFor replaces args for linters whose flags depend on their version.
+	// It is given the snippet's directory, where it may write a config file.
+	argsFor func(dir string) ([]string, error)
+}
+
+var pythonLinters = []linterCommand{
+	{name: "ruff", args: []string{"check", "--no-cache"}},
+	{name: "pyflakes"},
+}
+
+var javaScriptLinters = []linterCommand{
+	{name: "eslint", argsFor: eslintArgs},
+}
+
+// eslintFlatConfig is written next to the snippet for ESLint 9 and later,
+// which only read flat config files and reject the older settings flags.
+const eslintFlatConfig = `export default [
+  {
+    files: ["**/*.js"],
+    languageOptions: { ecmaVersion: "latest", sourceType: "module" },
+  },
+];
+`
+
+// eslintArgs checks the snippet on its own, ignoring any ESLint configuration
+// found around the temp directory.
+func eslintArgs(dir string) ([]string, error) {
+	out, err := exec.Command("eslint", "--version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get eslint version: %w", err)
+	}
+	if major, ok := majorVersion(string(out)); ok && major < 9 {
+		return []string{"--no-eslintrc", "--env", "browser,node,es2022", "--parser-options", "ecmaVersion:latest,sourceType:module"}, nil
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "eslint.config.mjs"), []byte(eslintFlatConfig), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write eslint config: %w", err)
+	}
+	return []string{"--no-config-lookup", "--config", "eslint.config.mjs"}, nil
+}
+
+// majorVersion reads the major version from output such as "v9.12.0".
+func majorVersion(version string) (int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	return n, err == nil
+}
+
+func newLinterTool(name, language, fileName string, linters []linterCommand) Tool {
+	return Tool{
+		Name:        name,
+		Description: fmt.Sprintf("Check %s code for errors and style issues using an installed linter.", language),
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"code": map[string]interface{}{
+					"type":        "string",
+					"description": fmt.Sprintf("The %s code to check for errors.", language),
+				},
+			},
+			"required": []string{"code"},
+		},
+		Executor: func(args map[string]string) (string, error) {
+			code := args["code"]
+			if code == "" {
+				return "", fmt.Errorf("code parameter not found in tool call")
+			}
+
+			return executeLinter(language, fileName, linters, code)
+		},
+	}
+}
+
+var (
+	checkPythonCodeTool     = newLinterTool("check_python_code", "Python", "snippet.py", pythonLinters)
+	checkJavaScriptCodeTool = newLinterTool("check_javascript_code", "JavaScript", "snippet.js", javaScriptLinters)
+)
+
+var defaultCommandDenylist = []string{
+	"rm -rf",
+	"mkfs",
+	"dd if=",
+	"shutdown",
+	"reboot",
+	"sudo",
+	":(){",
+}
+
+// commandPolicy is the allowlist and denylist run_command checks commands
+// against, copied from the config when an agent chain starts.
+type commandPolicy struct {
+	allowlist []string
+	denylist  []string
+}
+
+// newRunCommandTool returns the run_command tool, which asks program's user to
+// confirm each command that passes policy before running it.
+func newRunCommandTool(program *tea.Program, policy commandPolicy) Tool {
+	return Tool{
+		Name:        "run_command",
+		Description: "Run a shell command and return its combined stdout and stderr.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "The shell command to run.",
+				},
+			},
+			"required": []string{"command"},
+		},
+		Executor: func(args map[string]string) (string, error) {
+			return executeCommand(program, policy, args["command"])
+		},
+	}
+}
+
+// chainTools returns the tools for an agent chain about to start. run_command
+// gets its own copy of the command policy so the chain never reads the config
+// while Update may be changing it.
+func (m *model) chainTools() toolRegistry {
+	tools := make(toolRegistry, len(m.toolRegistry))
+	for name, tool := range m.toolRegistry {
+		tools[name] = tool
+	}
+	tools.register(newRunCommandTool(m.program, commandPolicy{
+		allowlist: append([]string(nil), m.config.CommandAllowlist...),
+		denylist:  append([]string(nil), m.config.CommandDenylist...),
+	}))
+	return tools
+}
+
+// checkCommandPolicy rejects commands that run a program matching a denylist
+// entry and, when an allowlist is configured, commands running a program that
+// isn't on it. Every program the command line runs is checked, including
+// those after ;, && or | and inside sh -c scripts. Entries that don't start
+// with a program name, such as the fork bomb ":(){", are matched against the
+// command with its whitespace removed.
+func checkCommandPolicy(command string, allowlist []string, denylist []string) error {
+	commands := shellCommands(command)
+	if len(commands) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	compact := strings.Join(strings.Fields(command), "")
+	for _, denied := range denylist {
+		denied = strings.TrimSpace(denied)
+		if denied == "" {
+			continue
+		}
+		if !startsWithProgram(denied) {
+			if strings.Contains(compact, strings.Join(strings.Fields(denied), "")) {
+				return fmt.Errorf("command blocked by denylist entry %q", denied)
+			}
+			continue
+		}
+		entry := splitShellWords(denied)[0]
+		for _, argv := range commands {
+			if runsDenied(argv, entry) {
+				return fmt.Errorf("command blocked by denylist entry %q", denied)
+			}
+		}
+	}
+
+	if len(allowlist) == 0 {
+		return nil
+	}
+	for _, argv := range commands {
+		if !slices.Contains(allowlist, filepath.Base(argv[0])) {
+			return fmt.Errorf("command %q is not in the allowlist", argv[0])
+		}
+	}
+	return nil
+}
+
+// startsWithProgram reports whether a denylist entry names a program, rather
+// than being a bare shell construct.
+func startsWithProgram(entry string) bool {
+	r, _ := utf8.DecodeRuneInString(entry)
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("/._", r)
+}
+
+// runsDenied reports whether argv runs the program named by the first word of
+// a denylist entry with all of the entry's other words among its arguments.
+// A word ending in "=" matches any argument it prefixes, and short flags
+// match however they are grouped, so "-rf" matches "-fr" and "-r -f".
+func runsDenied(argv []string, entry []string) bool {
+	if filepath.Base(argv[0]) != entry[0] {
+		return false
+	}
+	for _, want := range entry[1:] {
+		if !hasArgument(argv[1:], want) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasArgument(args []string, want string) bool {
+	for _, arg := range args {
+		if arg == want || (strings.HasSuffix(want, "=") && strings.HasPrefix(arg, want)) {
+			return true
+		}
+	}
+	if len(want) < 2 || want[0] != '-' || want[1] == '-' {
+		return false
+	}
+
+	var flags strings.Builder
+	for _, arg := range args {
+		if len(arg) > 1 && arg[0] == '-' && arg[1] != '-' {
+			flags.WriteString(arg[1:])
+		}
+	}
+	for _, flag := range want[1:] {
+		if !strings.ContainsRune(flags.String(), flag) {
+			return false
+		}
+	}
+	return true
+}
+
+// shellKeywords may start a command without being a program.
+var shellKeywords = map[string]bool{
+	"!": true, "{": true, "}": true, "if": true, "then": true, "else": true,
+	"elif": true, "do": true, "while": true, "until": true,
+}
+
+// commandPrefixes are programs that run the command following their options.
+var commandPrefixes = map[string]bool{
+	"time": true, "exec": true, "command": true, "builtin": true, "nohup": true,
+	"nice": true, "env": true, "sudo": true, "doas": true, "xargs": true,
+}
+
+var shellNames = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true}
+
+var envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// shellCommands returns the argv of each simple command a shell command line
+// runs. A prefix such as env or sudo is returned with its options as a
+// command of its own, followed by the command it runs, and sh -c and eval
+// scripts are split in turn, so the first word of each argv is a program that
+// actually runs. This is a best effort; the confirmation prompt remains the
+// real safeguard.
+func shellCommands(line string) [][]string {
+	var commands [][]string
+	for _, words := range splitShellWords(line) {
+		for len(words) > 0 {
+			if shellKeywords[words[0]] || envAssignment.MatchString(words[0]) {
+				words = words[1:]
+				continue
+			}
+			if !commandPrefixes[filepath.Base(words[0])] {
+				break
+			}
+			end := 1
+			for end < len(words) && strings.HasPrefix(words[end], "-") {
+				end++
+			}
+			commands = append(commands, words[:end])
+			words = words[end:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+		commands = append(commands, words)
+
+		program := filepath.Base(words[0])
+		if program == "eval" {
+			commands = append(commands, shellCommands(strings.Join(words[1:], " "))...)
+		}
+		if shellNames[program] {
+			// the script is the word after the option containing c, as in -c or -lc
+			for i := 1; i < len(words)-1; i++ {
+				if strings.HasPrefix(words[i], "-") && !strings.HasPrefix(words[i], "--") && strings.Contains(words[i], "c") {
+					commands = append(commands, shellCommands(words[i+1])...)
+					break
+				}
+			}
+		}
+	}
+	return commands
+}
+
+// splitShellWords splits a command line into the words of each simple command,
+// removing quotes and backslash escapes the way the shell would, so "\rm" and
+// "'rm'" both become rm. Command separators and parentheses end a command.
+func splitShellWords(line string) [][]string {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = nil
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				end = len(line) - i - 1
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			inWord = true
+		case c == ' ' || c == '\t':
+			endWord()
+		case strings.IndexByte(";&|\n()`", c) >= 0:
+			endCommand()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands
+}
+
+// executeCommand runs command once it passes policy and the user confirms it.
+func executeCommand(program *tea.Program, policy commandPolicy, command string) (string, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", fmt.Errorf("command parameter not found in tool call")
+	}
+
+	if err := checkCommandPolicy(command, policy.allowlist, policy.denylist); err != nil {
+		return "", err
+	}
+
+	if !confirmCommand(program, command) {
+		return "", fmt.Errorf("command %q was declined by the user", command)
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	output, err := cmd.CombinedOutput()
+
+	result := fmt.Sprintf("Command: %s\n\n```\n%s\n```", command, string(output))
+	if err != nil {
+		return result, fmt.Errorf("command failed: %w", err)
+	}
+	return result, nil
+}
+
+// confirmCommand asks the UI to confirm a command and blocks until the user
+// answers. It is called from the goroutine running the agent chain.
+func confirmCommand(program *tea.Program, command string) bool {
+	if program == nil {
+		return false
+	}
+
+	reply := make(chan bool)
+	program.Send(commandConfirmMsg{command: command, reply: reply})
+	return <-reply
+}
+
+func (m *model) recordToolUsage(usage ToolUsage) {
+	if m.program == nil {
+		return
+	}
+	m.program.Send(toolUsageMsg(usage))
+}
+
+func saveToolUsages(m *model) error {
+	data, err := json.MarshalIndent(m.toolUsages, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tool usages: %w", err)
+	}
+
+	err = writeFileAtomic(m.toolUsageFilePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write tool usages to file: %w", err)
+	}
+
+	return nil
 }
 
 func loadToolUsages(m *model) error {
@@ -46,31 +543,25 @@ func loadToolUsages(m *model) error {
 	return nil
 }
 
-func parseToolCall(jsonData []byte) (string, error) {
-	var toolCall struct {
-		Name       string `json:"name"`
-		Parameters struct {
-			Code string `json:"code"`
-		} `json:"parameters"`
-	}
-
-	if err := json.Unmarshal(jsonData, &toolCall); err != nil {
-		return "", fmt.Errorf("failed to unmarshal tool call: %w", err)
+func parseToolCall(name string, arguments json.RawMessage) (ToolCall, error) {
+	var rawArgs map[string]interface{}
+	if err := json.Unmarshal(arguments, &rawArgs); err != nil {
+		return ToolCall{}, fmt.Errorf("failed to unmarshal tool call: %w", err)
 	}
 
-	if toolCall.Parameters.Code == "" {
-		return "", fmt.Errorf("code parameter not found in tool call")
+	params := make(map[string]string, len(rawArgs))
+	for key, value := range rawArgs {
+		if str, ok := value.(string); ok {
+			params[key] = str
+		} else {
+			params[key] = fmt.Sprint(value)
+		}
 	}
 
-	code := toolCall.Parameters.Code
-	code = strings.ReplaceAll(code, "\\n", "\n")
-	code = strings.ReplaceAll(code, "\\\"", "\"")
-	code = strings.Trim(code, "\"\"\"")
-
-	return code, nil
+	return ToolCall{Name: name, Parameters: params}, nil
 }
 
-func executeGolangciLint(code string, agentRole string, m *model) (string, error) {
+func executeGolangciLint(code string) (string, error) {
 	if !strings.Contains(code, "package ") {
 		code = "package main\n\n" + code
 	}
@@ -101,19 +592,29 @@ func executeGolangciLint(code string, agentRole string, m *model) (string, error
 		return "", fmt.Errorf("failed to write code file: %w", err)
 	}
 
-	// run golangci-lint
-	cmd := exec.Command("golangci-lint", "run",
-		"--disable-all",
-		"--enable=govet",
-		"--enable=staticcheck",
-		"--enable=errcheck",
-		"--enable=gosimple",
-		"--enable=ineffassign",
-		"--enable=typecheck",
-		"--max-issues-per-linter=0",
-		"--max-same-issues=0")
-	cmd.Dir = tmpDir
-	lintOutput, err := cmd.CombinedOutput()
+	// golangci-lint is optional; fall back to go vet when it isn't installed
+	_, lookErr := exec.LookPath("golangci-lint")
+	hasGolangciLint := lookErr == nil
+
+	var lintOutput []byte
+	if hasGolangciLint {
+		cmd := exec.Command("golangci-lint", "run",
+			"--disable-all",
+			"--enable=govet",
+			"--enable=staticcheck",
+			"--enable=errcheck",
+			"--enable=gosimple",
+			"--enable=ineffassign",
+			"--enable=typecheck",
+			"--max-issues-per-linter=0",
+			"--max-same-issues=0")
+		cmd.Dir = tmpDir
+		lintOutput, err = cmd.CombinedOutput()
+	} else {
+		vetCmd := exec.Command("go", "vet", "./...")
+		vetCmd.Dir = tmpDir
+		lintOutput, err = vetCmd.CombinedOutput()
+	}
 
 	// run go build to catch compilation errors
 	buildCmd := exec.Command("go", "build", "./...")
@@ -123,6 +624,11 @@ func executeGolangciLint(code string, agentRole string, m *model) (string, error
 	var resultBuilder strings.Builder
 	resultBuilder.WriteString("Code Analysis Results:\n\n")
 
+	if !hasGolangciLint {
+		resultBuilder.WriteString("Note: golangci-lint was not found on PATH, so only go vet and go build were run. ")
+		resultBuilder.WriteString("Install it with `go install github.com/golangci/golangci-lint/cmd/golangci-lint@latest` for deeper checks.\n\n")
+	}
+
 	resultBuilder.WriteString("Formatted Code:\n```go\n")
 	resultBuilder.WriteString(formattedCode)
 	resultBuilder.WriteString("\n```\n\n")
@@ -135,7 +641,11 @@ func executeGolangciLint(code string, agentRole string, m *model) (string, error
 		resultBuilder.WriteString("Build Status: Success ✓\n\n")
 	}
 
-	resultBuilder.WriteString("Linter Results:\n")
+	if hasGolangciLint {
+		resultBuilder.WriteString("Linter Results:\n")
+	} else {
+		resultBuilder.WriteString("Vet Results:\n")
+	}
 	if err != nil && len(lintOutput) > 0 {
 		resultBuilder.WriteString("```\n")
 		resultBuilder.WriteString(string(lintOutput))
@@ -146,3 +656,139 @@ func executeGolangciLint(code string, agentRole string, m *model) (string, error
 
 	return resultBuilder.String(), nil
 }
+
+// executeLinter runs the first installed linter from linters against code. A
+// missing linter is reported in the output rather than treated as a failure.
+func executeLinter(language, fileName string, linters []linterCommand, code string) (string, error) {
+	var linter *linterCommand
+	for i := range linters {
+		if _, err := exec.LookPath(linters[i].name); err == nil {
+			linter = &linters[i]
+			break
+		}
+	}
+	if linter == nil {
+		names := make([]string, len(linters))
+		for i, l := range linters {
+			names[i] = l.name
+		}
+		return fmt.Sprintf("%s linter not installed (tried %s). Skipping checks.", language, strings.Join(names, ", ")), nil
+	}
+
+	// create temp dir to run checks
+	tmpDir, err := os.MkdirTemp("", "lint_*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	codeFile := filepath.Join(tmpDir, fileName)
+	if err := os.WriteFile(codeFile, []byte(code), 0644); err != nil {
+		return "", fmt.Errorf("failed to write code file: %w", err)
+	}
+
+	args := linter.args
+	if linter.argsFor != nil {
+		if args, err = linter.argsFor(tmpDir); err != nil {
+			return "", err
+		}
+	}
+
+	cmd := exec.Command(linter.name, append(args, fileName)...)
+	cmd.Dir = tmpDir
+	lintOutput, err := cmd.CombinedOutput()
+
+	// the supported linters exit with 1 when they find problems; anything
+	// else, such as a usage or config error, means the check didn't run
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() != 1 || len(lintOutput) == 0) {
+		return "", fmt.Errorf("failed to run %s: %w: %s", linter.name, err, strings.TrimSpace(string(lintOutput)))
+	}
+
+	var resultBuilder strings.Builder
+	resultBuilder.WriteString(fmt.Sprintf("Code Analysis Results (%s):\n\n", linter.name))
+
+	resultBuilder.WriteString("Linter Results:\n")
+	if err != nil {
+		resultBuilder.WriteString("```\n")
+		resultBuilder.WriteString(string(lintOutput))
+		resultBuilder.WriteString("\n```\n")
+	} else {
+		resultBuilder.WriteString("No linting issues found ✓\n")
+	}
+
+	return resultBuilder.String(), nil
+}
+
+// diagnosticLine matches tool output lines that point at a problem: a
+// file:line position or an error or warning.
+var diagnosticLine = regexp.MustCompile(`(?i):\d+(:\d+)?:|error|warning|fail`)
+
+// trimToolOutput shortens tool output fed back to a model to at most limit
+// bytes plus a truncation note. Lines reporting errors are kept in preference
+// to the rest; if none fit, the start of the output is kept. A limit of 0 or
+// less leaves the output alone.
+func trimToolOutput(output string, limit int) string {
+	if limit <= 0 || len(output) <= limit {
+		return output
+	}
+
+	var kept strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		if !diagnosticLine.MatchString(line) {
+			continue
+		}
+		if kept.Len()+len(line)+1 > limit {
+			break
+		}
+		kept.WriteString(line)
+		kept.WriteString("\n")
+	}
+
+	trimmed := kept.String()
+	note := "only the lines reporting problems are shown"
+	if trimmed == "" {
+		cut := limit
+		// back up to a rune boundary so the note isn't glued to half a character
+		for cut > 0 && !utf8.RuneStart(output[cut]) {
+			cut--
+		}
+		trimmed = output[:cut] + "\n"
+		note = "only the start is shown"
+	}
+	return trimmed + fmt.Sprintf("[tool output truncated from %d to %d bytes; %s]", len(output), len(trimmed), note)
+}
+
+// populateToolUsageTable lists tool usages newest first.
+func (m *model) populateToolUsageTable() {
+	rows := make([]table.Row, 0, len(m.toolUsages))
+
+	for i := len(m.toolUsages) - 1; i >= 0; i-- {
+		usage := m.toolUsages[i]
+
+		status := "ok"
+		details := usage.Output
+		if !usage.Success {
+			status = "failed"
+			details = usage.ErrorMessage
+		}
+		details = strings.Join(strings.Fields(details), " ")
+
+		rows = append(rows, table.Row{
+			usage.Timestamp.Format("2006-01-02 15:04:05"),
+			usage.AgentRole,
+			usage.ToolName,
+			status,
+			details,
+		})
+	}
+
+	m.toolUsageTable.SetRows(rows)
+}
+
+func (m model) toolUsageView() string {
+	if len(m.toolUsages) == 0 {
+		return "Tool Usages:\n\nNo tools have been run yet.\n\nPress esc to go back."
+	}
+	return fmt.Sprintf("Tool Usages (%d):\n\n%s\n\nPress esc to go back.", len(m.toolUsages), m.toolUsageTable.View())
+}
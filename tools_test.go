@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestCheckCommandPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		allowlist []string
+		blocked   bool
+	}{
+		{name: "harmless command", command: "ls -la"},
+		{name: "denied word as an argument", command: "grep shutdown /var/log/syslog"},
+		{name: "denied flags without their program", command: "echo rm -rf /"},
+		{name: "denied program", command: "rm -rf /tmp/x", blocked: true},
+		{name: "extra whitespace", command: "rm   -rf  /tmp/x", blocked: true},
+		{name: "split flags", command: "rm -r -f /tmp/x", blocked: true},
+		{name: "reordered flags", command: "rm -fr /tmp/x", blocked: true},
+		{name: "escaped program", command: `\rm -rf /tmp/x`, blocked: true},
+		{name: "quoted program", command: `"rm" -rf /tmp/x`, blocked: true},
+		{name: "program path", command: "/bin/rm -rf /tmp/x", blocked: true},
+		{name: "after a separator", command: "cd /tmp && rm -rf x", blocked: true},
+		{name: "inside sh -c", command: `sh -c "rm -rf /tmp/x"`, blocked: true},
+		{name: "after a prefix", command: "env FOO=1 nohup rm -rf /tmp/x", blocked: true},
+		{name: "argument prefix entry", command: "dd if=/dev/zero of=/dev/sda", blocked: true},
+		{name: "denied prefix", command: "sudo ls", blocked: true},
+		{name: "fork bomb", command: ":() { :|:& };:", blocked: true},
+		{name: "allowed program", command: "ls -la", allowlist: []string{"ls"}},
+		{name: "unlisted program after an allowed one", command: "ls; cat /etc/passwd", allowlist: []string{"ls"}, blocked: true},
+		{name: "unlisted program inside sh -c", command: `sh -c "cat /etc/passwd"`, allowlist: []string{"sh"}, blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCommandPolicy(tt.command, tt.allowlist, defaultCommandDenylist)
+			if blocked := err != nil; blocked != tt.blocked {
+				t.Errorf("checkCommandPolicy(%q) = %v, want blocked %v", tt.command, err, tt.blocked)
+			}
+		})
+	}
+}
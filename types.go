@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"time"
 
 	"github.com/charmbracelet/bubbles/filepicker"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
@@ -29,12 +33,45 @@ const (
 	ChatListView
 	NewChatFormView
 	FilePickerView
+	RenameChatFormView
+	ExportFormView
+	MessageSelectView
+	ToolUsageView
+	AgentExportFormView
+	AgentImportFormView
+	SaveChatFormView
+	PullModelFormView
+	PromptPreviewView
+	QuantizationView
+	CreateModelFormView
+	ModelDetailView
+	ProjectFormView
+	AgentChainView
+	ModelSwitchFormView
+	SemanticSearchFormView
+	SemanticSearchView
+	AgentToolsFormView
 )
 
 const (
 	defaultSystemPrompt     = ""
 	defaultContextFilePath  = ""
-	defaultTokens           = "2048"
+	maxContextBytes         = 64 * 1024
+	defaultToolOutputLimit  = 8 * 1024
+	defaultEmbeddingModel   = "nomic-embed-text"
+	embeddingsFolderName    = "embeddings"
+	maxEmbeddingBytes       = 8 * 1024
+	maxSemanticResults      = 50
+	maxAgentOrderUndo       = 20
+	ollamaToggleTimeout     = 10 * time.Second
+	defaultHealthInterval   = 5 * time.Second
+	toastDuration           = 4 * time.Second
+	autoSaveDelay           = 500 * time.Millisecond
+	overflowTruncate        = "truncate"
+	overflowSummarize       = "summarize"
+	omittedMessagesNote     = "[earlier messages omitted]"
+	defaultTokens           = "2048" // must match defaultContextWindow
+	defaultContextWindow    = 2048
 	defaultModelVersion     = ""
 	ollamaAPIURL            = "http://localhost:11434/api"
 	defaultIndicatorPrompt  = "│"
@@ -42,9 +79,20 @@ const (
 	agentFormTitle          = "Agent Configuration"
 	confirmDeleteAgentTitle = "Confirm Agent Deletion"
 	confirmDeleteModelTitle = "Confirm Model Deletion"
-	agentsFilePath          = "./agents.json"
+	agentsFileName          = "agents.json"
+	projectsFileName        = "projects.json"
+	configFileName          = "config.json"
+	toolUsageFileName       = "tool_usages.json"
+	libraryCacheFileName    = "available_models_cache.json"
+	chatsFolderName         = "chats"
+	defaultDataDir          = "."
+	defaultLibraryCacheTTL  = 24 * time.Hour
 )
 
+// defaultAttachmentTypes are the extensions the file picker offers when the
+// attachment_types config entry is empty.
+var defaultAttachmentTypes = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
 type model struct {
 	userMessages           []string
 	assistantResponses     []string
@@ -53,13 +101,26 @@ type model struct {
 	err                    error
 	textarea               textarea.Model
 	viewport               viewport.Model
+	previewViewport        viewport.Model
 	modelTable             table.Model
 	availableTable         table.Model
 	parameterSizesTable    table.Model
+	quantizationTable      table.Model
+	quantizationTags       []string // quantization variants of selectedModelSize
+	selectedModelSize      string
+	quantizationsLoading   bool
 	width                  int
 	height                 int
 	loading                bool
+	toast                  string // transient notice shown at the bottom of the view
+	toastID                int
+	autoSaveID             int  // bumped per scheduled save; stale ticks are ignored
+	autoSavePending        bool // a scheduled save hasn't run yet
+	modelsLoading          bool // installed models are being fetched
 	renderer               *glamour.TermRenderer
+	rendererWidth          int    // word wrap width renderer was built with
+	rendererStyle          string // markdown style renderer was built with
+	rawOutput              bool   // show messages as plain text instead of rendered markdown
 	ollamaRunning          bool
 	config                 ChatConfig
 	configForm             *huh.Form
@@ -70,10 +131,13 @@ type model struct {
 	confirmResult          bool
 	confirmDeleteType      string
 	availableModels        []AvailableModel
+	availableModelsCached  bool
+	availableModelsFetched time.Time
 	selectedAvailableModel AvailableModel
 	spinner                spinner.Model
 	agentsTable            table.Model
 	agents                 []Agent
+	savedAgents            []byte // agents as last read from or written to disk
 	selectedAgent          Agent
 	agentViewMode          viewMode
 	agentFormActive        bool
@@ -81,21 +145,97 @@ type model struct {
 	agentAction            string
 	agentToDelete          string
 	currentEditingAgent    Agent
+	agentTransferForm      *huh.Form
+	agentTransferPath      string
+	agentTransferOption    string
 	availableModelVersions []string
 	modelsFetchError       error
-	errorMessage           string
+	errorMessage           string            // errors only; notices go through toast
+	startupNotice          string            // toast shown once the program starts
+	drafts                 map[string]string // unsent textarea content by chat ID
+	markedChats            map[string]bool   // chat IDs selected for bulk deletion
+	missingModel           string
+	ollamaUnreachable      bool // the shown error came from a refused connection
 	availableTools         []Tool
+	toolRegistry           toolRegistry
+	program                *tea.Program
+	pendingCommand         *commandConfirmMsg
+	queuedCommands         []commandConfirmMsg // commands waiting for the open confirm or form to close
+	viewBeforeConfirm      viewMode
+	retryStatus            string
+	showHelp               bool
 	toolUsages             []ToolUsage
 	toolUsageFilePath      string
+	agentsFilePath         string
+	projectsFilePath       string
+	configFilePath         string
+	libraryCachePath       string
+	toolUsageTable         table.Model
 	chats                  []Chat
 	chatList               list.Model
 	selectedChat           *Chat
+	chatToDelete           string
+	chatToRename           string
+	discardAction          string
 	chatsFolderPath        string
 	newChatForm            *huh.Form
+	exportForm             *huh.Form
+	exportFileName         string
 	newChatName            string
 	newProjectName         string
 	filePicker             filepicker.Model
 	selectedImage          string
+	pendingImages          []string
+	pendingFiles           []string // text files sent as context with the next message
+	pickingTextFile        bool     // the file picker is choosing a file for pendingFiles
+	attachmentTypes        []string // extensions the picker offers for images
+	downloadProgress       progress.Model
+	downloadingModel       string
+	cancelPull             context.CancelFunc // aborts the download or create in progress
+	pullModelForm          *huh.Form
+	modelSwitchForm        *huh.Form
+	agentToolsForm         *huh.Form
+	agentToolsRole         string
+	agentToolsSelection    []string
+	tableOffsets           map[viewMode]int // first visible row of each view's table
+	modelSwitchRole        string
+	modelSwitchVersion     string
+	modelSwitchSave        bool
+	modelOverrides         map[string]string // session-only model by agent role
+	semanticForm           *huh.Form
+	semanticQuery          string
+	semanticSearching      bool
+	semanticResults        []semanticResult
+	semanticTable          table.Model
+	pullModelName          string
+	createModelForm        *huh.Form
+	createModelName        string
+	modelfilePath          string
+	installedModels        []OllamaModel
+	chatSortOrder          chatSortOrder
+	agentOrderUndo         []agentOrder // newest last, capped at maxAgentOrderUndo
+	projects               map[string]ProjectSettings
+	projectForm            *huh.Form
+	editingProject         string
+	projectPromptDraft     string
+	progressVerb           string // "Downloading" or "Creating" in DownloadingView
+	pullStatus             string
+	pullTotal              int64
+	pullCompleted          int64
+	renderedHistory        string
+	searchInput            textinput.Model
+	searchActive           bool
+	searchQuery            string
+	searchMatches          []int
+	searchIndex            int
+	availableFilter        textinput.Model
+	availableFilterActive  bool
+	availableFiltered      []AvailableModel // availableModels matching the filter
+	availablePage          int
+	messageOffsets         []int
+	selectedMessage        int
+	editingMessage         int
+	editedMessage          int
 }
 
 type OllamaModel struct {
@@ -103,12 +243,25 @@ type OllamaModel struct {
 	Model      string    `json:"model"`
 	ModifiedAt time.Time `json:"modified_at"`
 	Size       int64     `json:"size"`
+	Digest     string    `json:"digest"`
 	Details    struct {
+		Format            string `json:"format"`
+		Family            string `json:"family"`
 		ParameterSize     string `json:"parameter_size"`
 		QuantizationLevel string `json:"quantization_level"`
 	} `json:"details"`
 }
 
+// ModelInfo is Ollama's /show response for an installed model.
+type ModelInfo struct {
+	License    string         `json:"license"`
+	Modelfile  string         `json:"modelfile"`
+	Parameters string         `json:"parameters"`
+	Template   string         `json:"template"`
+	System     string         `json:"system"`
+	ModelInfo  map[string]any `json:"model_info"`
+}
+
 type AvailableModel struct {
 	Name  string   `json:"name"`
 	Sizes []string `json:"sizes"`
@@ -123,10 +276,23 @@ type PullResponse struct {
 }
 
 type ChatConfig struct {
-	ModelVersion    string
-	SystemPrompt    string
-	ContextFilePath string
-	Tokens          string
+	ModelVersion     string   `json:"model_version"`
+	SystemPrompt     string   `json:"system_prompt"`
+	ContextFilePath  string   `json:"context_file_path"`
+	Tokens           string   `json:"tokens"`
+	CommandAllowlist []string `json:"command_allowlist"`
+	CommandDenylist  []string `json:"command_denylist"`
+	LibraryCacheTTL  string   `json:"library_cache_ttl"`
+	MaxRetries       int      `json:"max_retries"`
+	VisionModels     []string `json:"vision_models"`
+	Theme            string   `json:"theme"`
+	MarkdownStyle    string   `json:"markdown_style"`
+	AttachmentDir    string   `json:"attachment_dir"`
+	AttachmentTypes  []string `json:"attachment_types"`
+	ToolOutputLimit  int      `json:"tool_output_limit"`
+	EmbeddingModel   string   `json:"embedding_model"`
+	ShowTimestamps   bool     `json:"show_timestamps"`
+	HealthInterval   string   `json:"health_check_interval"`
 }
 
 type Chat struct {
@@ -135,30 +301,119 @@ type Chat struct {
 	ProjectName string              `json:"project_name"`
 	CreatedAt   time.Time           `json:"created_at"`
 	Messages    []map[string]string `json:"messages"`
+	Pinned      bool                `json:"pinned,omitempty"`
+	Draft       string              `json:"draft,omitempty"` // unsent textarea content
+}
+
+// agentOrder records the agent chain order by role, and the table row the
+// cursor was on, before a move so it can be undone.
+type agentOrder struct {
+	roles  []string
+	cursor int
 }
+
+// ProjectSettings are the defaults shared by every chat in a project, stored
+// in projects.json keyed by project name.
+type ProjectSettings struct {
+	SystemPrompt string `json:"system_prompt"`
+}
+
 type chatItem struct {
 	chat Chat
 }
 
+// projectHeaderItem is a non-selectable row naming the project the chats
+// below it belong to.
+type projectHeaderItem struct {
+	project string
+	count   int
+	pinned  bool // heads the pinned chats rather than a project
+}
+
+// chatSortOrder is how chats are ordered within each project group.
+type chatSortOrder int
+
+const (
+	sortChatsByDate chatSortOrder = iota
+	sortChatsByName
+	sortChatsByMessages
+)
+
 type chatDelegate struct {
 	styles struct {
-		normal, selected lipgloss.Style
+		normal, selected, header lipgloss.Style
 	}
+	marked map[string]bool // shared with model.markedChats
 }
 
 type (
-	responseMsg        string
-	errMsg             error
-	modelsMsg          []OllamaModel
-	availableModelsMsg []AvailableModel
-	modelDeletedMsg    struct{}
-	modelDownloadedMsg string
-	scrapeCompletedMsg struct{}
-	agentsMsg          []Agent
-	notifyMsg          string
-	OllamaToggledMsg   struct{}
+	errMsg              error
+	modelsMsg           []OllamaModel
+	modelDeletedMsg     struct{}
+	modelDownloadedMsg  string
+	scrapeCompletedMsg  struct{}
+	agentsMsg           []Agent
+	notifyMsg           string
+	ollamaStatusMsg     bool
+	ollamaHealthTickMsg struct{}
+	ollamaHealthMsg     bool // result of a background health check
+	ollamaRestartedMsg  struct{}
+	toastExpiredMsg     int
+	autoSaveMsg         int
 )
 
+type availableModelsMsg struct {
+	models    []AvailableModel
+	cached    bool
+	fetchedAt time.Time
+}
+
+// chatCompletedMsg carries the outcome of an agent chain back to Update: the
+// conversation including every reply produced before the chain finished or
+// failed, and err if it failed.
+type chatCompletedMsg struct {
+	history      []map[string]string
+	lastResponse string
+	skipped      []string // roles of ContinueOnError agents that failed
+	err          error
+}
+
+// responseStats holds the token count and timings Ollama reports with a
+// chat response.
+type responseStats struct {
+	EvalCount     int
+	EvalDuration  time.Duration
+	TotalDuration time.Duration
+}
+
+// responseContinuedMsg carries the text an agent added to the response at
+// index when asked to continue it.
+type responseContinuedMsg struct {
+	index int
+	text  string
+	stats responseStats
+	err   error
+}
+
+// semanticSearchMsg carries the ranked matches of a semantic chat search.
+type semanticSearchMsg struct {
+	results []semanticResult
+	err     error
+}
+
+// modelTagsMsg carries the tags published for the selected model.
+type modelTagsMsg struct {
+	tags []string
+	err  error
+}
+
+// modelDetailsMsg carries the /show response for model.
+type modelDetailsMsg struct {
+	model OllamaModel
+	info  *ModelInfo
+	err   error
+}
+
 type agentDeletedMsg struct {
 	Role string
 }
@@ -167,12 +422,36 @@ type agentUpdatedMsg struct {
 	Role string
 }
 
+// agentsSavedMsg carries the agents data saveAgentsCmd wrote to disk.
+type agentsSavedMsg struct {
+	data []byte
+}
+
 type initialTransitionMsg struct{}
 
+type commandConfirmMsg struct {
+	command string
+	reply   chan bool
+}
+
+type toolUsageMsg ToolUsage
+
+type retryMsg struct {
+	attempt     int
+	maxAttempts int
+	err         error
+}
+
+type pullProgressMsg struct {
+	PullResponse
+	ch <-chan tea.Msg
+}
+
 type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	Parameters  map[string]interface{} `json:"parameters"`
+	Executor    ToolExecutor           `json:"-"`
 }
 
 type ToolUsage struct {
@@ -200,4 +479,27 @@ type Agent struct {
 	Tokens          string   `json:"tokens"`
 	Tools           []Tool   `json:"tools,omitempty"`
 	SelectedTools   []string `json:"selected_tools,omitempty"`
+	Parallel        bool     `json:"parallel,omitempty"`
+	UseChainInput   bool     `json:"use_chain_input"`
+	ContinueOnError bool     `json:"continue_on_error,omitempty"`
+	Temperature     string   `json:"temperature,omitempty"`
+	TopP            string   `json:"top_p,omitempty"`
+	TopK            string   `json:"top_k,omitempty"`
+	RepeatPenalty   string   `json:"repeat_penalty,omitempty"`
+	MaxTokens       string   `json:"max_tokens,omitempty"`
+	StopSequences   []string `json:"stop_sequences,omitempty"`
+	Enabled         bool     `json:"enabled"`
+	ContextOverflow string   `json:"context_overflow,omitempty"`
+	ForceJSON       bool     `json:"force_json,omitempty"`
+	JSONSchema      string   `json:"json_schema,omitempty"` // sent as the format when ForceJSON is set
+	TimeoutSeconds  string   `json:"timeout_seconds,omitempty"`
+	FallbackModel   string   `json:"fallback_model,omitempty"` // used when ModelVersion isn't installed
+
+	// stopInput holds the comma-separated stop sequences while the agent form
+	// is open.
+	stopInput string
+
+	// fallbackFrom is the uninstalled model a request fell back from, set
+	// while processAgentChain retries with FallbackModel.
+	fallbackFrom string
 }
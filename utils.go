@@ -4,9 +4,14 @@ import (
 	"bufio"
 	"encoding/base64"
 	"fmt"
+	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -16,29 +21,85 @@ func FormatSizeGB(size int64) string {
 	return fmt.Sprintf("%.1f GB", gb)
 }
 
-func extractCodeBlocks(input string) []string {
-	var codeBlocks []string
+// estimateTokens gives a rough token count using the common ~4 characters per
+// token heuristic.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+func estimateHistoryTokens(history []map[string]string) int {
+	total := 0
+	for _, msg := range history {
+		total += estimateTokens(msg["content"])
+	}
+	return total
+}
+
+// codeBlock is a fenced block from a message together with its normalised
+// language tag. Untagged blocks that look like Go are reported as "go".
+type codeBlock struct {
+	Language string
+	Code     string
+}
+
+var languageAliases = map[string]string{
+	"golang":  "go",
+	"py":      "python",
+	"python3": "python",
+	"js":      "javascript",
+	"jsx":     "javascript",
+	"mjs":     "javascript",
+	"node":    "javascript",
+}
+
+func normalizeLanguage(tag string) string {
+	fields := strings.Fields(strings.ToLower(tag))
+	if len(fields) == 0 {
+		return ""
+	}
+	if alias, ok := languageAliases[fields[0]]; ok {
+		return alias
+	}
+	return fields[0]
+}
+
+// looksLikeGo is a cheap guess for untagged fences: a package clause or a top
+// level func declaration is enough to hand the block to the Go checker.
+func looksLikeGo(code string) bool {
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package ") || strings.HasPrefix(line, "func ") {
+			return true
+		}
+	}
+	return false
+}
+
+func extractCodeBlocks(input string) []codeBlock {
+	var codeBlocks []codeBlock
 	var currentBlock strings.Builder
+	var language string
 	inCodeBlock := false
-	isGoBlock := false
 
 	scanner := bufio.NewScanner(strings.NewReader(input))
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		if strings.HasPrefix(line, "```") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
 			if !inCodeBlock {
 				inCodeBlock = true
-				isGoBlock = strings.HasPrefix(line, "```go")
+				language = normalizeLanguage(strings.TrimPrefix(strings.TrimSpace(line), "```"))
 				currentBlock.Reset()
 			} else {
-				if isGoBlock {
-					codeBlocks = append(codeBlocks, currentBlock.String())
+				code := currentBlock.String()
+				if language == "" && looksLikeGo(code) {
+					language = "go"
 				}
+				codeBlocks = append(codeBlocks, codeBlock{Language: language, Code: code})
 				inCodeBlock = false
-				isGoBlock = false
+				language = ""
 			}
-		} else if inCodeBlock && isGoBlock {
+		} else if inCodeBlock {
 			currentBlock.WriteString(line + "\n")
 		}
 	}
@@ -46,32 +107,212 @@ func extractCodeBlocks(input string) []string {
 	return codeBlocks
 }
 
-// WIP: image inputs for mm models
-func (m *model) loadImageAsBase64(path string) (string, error) {
+// codeLanguages returns the set of languages found across the given blocks.
+func codeLanguages(blocks []codeBlock) map[string]bool {
+	languages := make(map[string]bool)
+	for _, block := range blocks {
+		if block.Language != "" {
+			languages[block.Language] = true
+		}
+	}
+	return languages
+}
+
+// loadImageAsBase64 returns the raw base64 encoding expected in the images
+// array of an Ollama chat message. Only the extensions in types, the ones the
+// file picker offers, are accepted.
+func loadImageAsBase64(path string, types []string) (string, error) {
+	ext := filepath.Ext(path)
+	supported := false
+	for _, allowed := range types {
+		if strings.EqualFold(ext, allowed) {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return "", fmt.Errorf("unsupported image format: %s", strings.ToLower(ext))
+	}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read image: %w", err)
 	}
 
-	ext := strings.ToLower(filepath.Ext(path))
-	var mimeType string
-	switch ext {
-	case ".jpg", ".jpeg":
-		mimeType = "image/jpeg"
-	case ".png":
-		mimeType = "image/png"
-	case ".gif":
-		mimeType = "image/gif"
-	case ".webp":
-		mimeType = "image/webp"
-	default:
-		return "", fmt.Errorf("unsupported image format: %s", ext)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func loadImages(paths []string, types []string) (images []string, names []string, err error) {
+	for _, path := range paths {
+		image, err := loadImageAsBase64(path, types)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, image)
+		names = append(names, filepath.Base(path))
+	}
+	return images, names, nil
+}
+
+// toAPIMessages converts stored messages into the chat API format, expanding
+// the comma-separated "images" key into an array and dropping display-only
+// keys.
+func toAPIMessages(messages []map[string]string) []map[string]interface{} {
+	apiMessages := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		apiMessage := map[string]interface{}{
+			"role":    msg["role"],
+			"content": msg["content"],
+		}
+		if images := msg["images"]; images != "" {
+			apiMessage["images"] = strings.Split(images, ",")
+		}
+		apiMessages = append(apiMessages, apiMessage)
+	}
+	return apiMessages
+}
+
+// configureFilePicker opens the picker in the folder of the last attachment
+// and limits it to the configured file types.
+func (m *model) configureFilePicker() {
+	if dir := m.config.AttachmentDir; dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			m.filePicker.CurrentDirectory = dir
+		} else {
+			log.Printf("Attachment directory %q is unavailable, starting in %s", dir, m.filePicker.CurrentDirectory)
+		}
+	}
+
+	if len(m.config.AttachmentTypes) > 0 {
+		types := make([]string, 0, len(m.config.AttachmentTypes))
+		for _, ext := range m.config.AttachmentTypes {
+			ext = strings.TrimSpace(ext)
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			types = append(types, ext)
+		}
+		m.filePicker.AllowedTypes = types
+	}
+	m.attachmentTypes = m.filePicker.AllowedTypes
+}
+
+// openFilePicker shows the file picker, for an image or, when text is true,
+// for a text file to send as context with the next message.
+func (m *model) openFilePicker(text bool) tea.Cmd {
+	m.pickingTextFile = text
+	if text {
+		m.filePicker.AllowedTypes = nil
+	} else {
+		m.filePicker.AllowedTypes = m.attachmentTypes
+	}
+	m.viewMode = FilePickerView
+	m.textarea.Blur()
+	return m.filePicker.Init()
+}
+
+func (m *model) updateFilePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.filePicker, cmd = m.filePicker.Update(msg)
+
+	if didSelect, path := m.filePicker.DidSelectFile(msg); didSelect {
+		if m.pickingTextFile {
+			if text, err := isTextFile(path); err != nil || !text {
+				return m, m.showToast(fmt.Sprintf("%s isn't a readable text file.", filepath.Base(path)))
+			}
+			m.pendingFiles = append(m.pendingFiles, path)
+		} else {
+			m.pendingImages = append(m.pendingImages, path)
+			m.selectedImage = path
+		}
+		m.viewMode = ChatView
+
+		// start in this folder next time, including after a restart
+		if dir := filepath.Dir(path); dir != m.config.AttachmentDir {
+			m.config.AttachmentDir = dir
+			if err := saveConfig(m); err != nil {
+				log.Printf("Failed to save config: %v", err)
+			}
+		}
+		return m, nil
 	}
 
-	base64Data := base64.StdEncoding.EncodeToString(data)
-	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64Data), nil
+	return m, cmd
 }
 
 func keyIsCtrlZ(msg tea.KeyMsg) bool {
 	return msg.Type == tea.KeyCtrlZ
 }
+
+// writeFileAtomic writes data to a temp file next to path and renames it over
+// path, so a crash mid-write leaves the old file intact instead of truncated
+// JSON.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// corruptFileError is a state file that exists but can't be parsed.
+type corruptFileError struct {
+	path string
+	err  error
+}
+
+func (e *corruptFileError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %v", e.path, e.err)
+}
+
+func (e *corruptFileError) Unwrap() error {
+	return e.err
+}
+
+// quarantineFile moves a corrupt file aside so it isn't overwritten and can
+// be repaired by hand, returning its new path.
+func quarantineFile(path string) (string, error) {
+	dest := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// openInFileManager opens dir with the platform's file manager without
+// waiting for it to exit.
+func openInFileManager(dir string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", dir)
+	case "windows":
+		cmd = exec.Command("explorer", dir)
+	default:
+		cmd = exec.Command("xdg-open", dir)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []codeBlock
+	}{
+		{
+			name:  "no code",
+			input: "Just some prose without any fences.",
+			want:  nil,
+		},
+		{
+			name:  "go fence",
+			input: "Check this:\n```go\npackage main\n```\n",
+			want:  []codeBlock{{Language: "go", Code: "package main\n"}},
+		},
+		{
+			name:  "golang fence",
+			input: "```golang\nfunc main() {}\n```",
+			want:  []codeBlock{{Language: "go", Code: "func main() {}\n"}},
+		},
+		{
+			name:  "untagged go",
+			input: "```\npackage main\n\nfunc main() {}\n```",
+			want:  []codeBlock{{Language: "go", Code: "package main\n\nfunc main() {}\n"}},
+		},
+		{
+			name:  "untagged non-go",
+			input: "```\nls -la\n```",
+			want:  []codeBlock{{Language: "", Code: "ls -la\n"}},
+		},
+		{
+			name:  "fence with attributes",
+			input: "```Go title=\"main.go\"\nvar x = 1\n```",
+			want:  []codeBlock{{Language: "go", Code: "var x = 1\n"}},
+		},
+		{
+			name: "mixed languages",
+			input: "First the script:\n```python\nprint('hi')\n```\n" +
+				"Then the service:\n```go\npackage main\n```\n" +
+				"And the config:\n```yaml\nkey: value\n```\n" +
+				"Finally:\n```golang\nfunc f() {}\n```\n",
+			want: []codeBlock{
+				{Language: "python", Code: "print('hi')\n"},
+				{Language: "go", Code: "package main\n"},
+				{Language: "yaml", Code: "key: value\n"},
+				{Language: "go", Code: "func f() {}\n"},
+			},
+		},
+		{
+			name:  "unterminated fence",
+			input: "```go\npackage main\n",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractCodeBlocks(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractCodeBlocks() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeLanguages(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]bool
+	}{
+		{
+			name:  "no go in mixed conversation",
+			input: "```python\nprint(1)\n```\n```bash\necho hi\n```\n```\nplain text\n```",
+			want:  map[string]bool{"python": true, "bash": true},
+		},
+		{
+			name:  "go alongside other languages",
+			input: "```js\nconsole.log(1)\n```\n```golang\npackage main\n```",
+			want:  map[string]bool{"javascript": true, "go": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codeLanguages(extractCodeBlocks(tt.input))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("codeLanguages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckerLanguage(t *testing.T) {
+	language, ok := checkerLanguage(checkGoCodeTool.Name)
+	if !ok || language != "go" {
+		t.Errorf("checkerLanguage(%q) = %q, %v, want \"go\", true", checkGoCodeTool.Name, language, ok)
+	}
+}
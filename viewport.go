@@ -3,7 +3,10 @@ package main
 import (
 	"fmt"
 	"log"
-	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,10 +14,10 @@ import (
 )
 
 var (
-	runningIndicatorColor = lipgloss.Color("#00FF00")
-	stoppedIndicatorColor = lipgloss.Color("#FF0000")
+	runningIndicatorColor = activeTheme.Accent
+	stoppedIndicatorColor = activeTheme.Error
 	errorStyle            = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FF0000")).
+				Foreground(activeTheme.Error).
 				Bold(true)
 )
 
@@ -29,7 +32,7 @@ func setupTextarea() textarea.Model {
 	ta.SetHeight(3)
 
 	indicatorStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
+		Foreground(activeTheme.Foreground).
 		Render(defaultIndicatorPrompt)
 
 	ta.Prompt = indicatorStyle
@@ -46,19 +49,11 @@ func (m *model) indicatorStyle() lipgloss.Style {
 
 	return lipgloss.NewStyle().
 		Foreground(color).
-		Background(lipgloss.Color("#000000")).
+		Background(activeTheme.Background).
 		Border(lipgloss.HiddenBorder()).
 		Padding(0)
 }
 
-func loadFileContext(filePath string) (string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
-	}
-	return string(content), nil
-}
-
 func (m *model) updateTextareaIndicatorColor() {
 	if m.ollamaRunning {
 		m.textarea.Prompt = lipgloss.NewStyle().
@@ -71,51 +66,604 @@ func (m *model) updateTextareaIndicatorColor() {
 	}
 }
 
-func sendChatMessage(m *model) tea.Cmd {
-	return func() tea.Msg {
-		if m.currentUserMessage == "" {
-			log.Println("No user message to send.")
-			return nil
+// tokenStatusLine summarises the draft size against the smallest context
+// window in the agent chain.
+func (m model) tokenStatusLine() string {
+	draft := m.textarea.Value()
+	draftTokens := estimateTokens(draft)
+	total := draftTokens
+
+	limit := 0
+	includesHistory := false
+	for _, agent := range m.enabledAgents() {
+		numCtx := agent.contextWindow()
+		if limit == 0 || numCtx < limit {
+			limit = numCtx
+		}
+		if agent.UseConversation {
+			includesHistory = true
 		}
+	}
+
+	status := fmt.Sprintf("%d chars | ~%d tokens", utf8.RuneCountInString(draft), draftTokens)
+	if len(m.pendingImages) > 0 {
+		status = fmt.Sprintf("%d image(s) attached | %s", len(m.pendingImages), status)
+	}
+	if len(m.pendingFiles) > 0 {
+		status = fmt.Sprintf("%d file(s) attached | %s", len(m.pendingFiles), status)
+	}
+	if includesHistory {
+		historyTokens := estimateHistoryTokens(m.conversationHistory)
+		total += historyTokens
+		status += fmt.Sprintf(" + ~%d history = ~%d", historyTokens, total)
+	}
+	if limit > 0 {
+		status += fmt.Sprintf(" / %d ctx", limit)
+	}
 
-		m.conversationHistory = append(m.conversationHistory, map[string]string{
-			"role":    "user",
-			"content": m.currentUserMessage,
-		})
+	style := lipgloss.NewStyle().Foreground(activeTheme.Muted)
+	if limit > 0 && total > limit {
+		style = errorStyle
+	}
+	return style.Render(status)
+}
 
-		if len(m.agents) == 0 {
-			return errMsg(fmt.Errorf("no agents configured"))
+// chatRequest is everything an agent chain needs, captured on the main loop
+// so the chain never reads the model while Update may be changing it.
+type chatRequest struct {
+	message       string
+	history       []map[string]string // ends with the user message being sent
+	images        []string
+	agents        []Agent
+	tools         toolRegistry
+	visionModels  []string
+	recordUsage   func(ToolUsage)
+	projectPrompt string // inherited by agents without their own prompt
+	toolOutputMax int    // bytes of failing tool output sent back for analysis
+}
+
+// toolOutputLimit is how much failing tool output is sent back to a model for
+// analysis: the configured tool_output_limit, the default when it is unset,
+// or no limit when it is negative.
+func (m *model) toolOutputLimit() int {
+	if m.config.ToolOutputLimit == 0 {
+		return defaultToolOutputLimit
+	}
+	return m.config.ToolOutputLimit
+}
+
+// sendChatMessage adds the current user message to the conversation and runs
+// it through the enabled agents in the background. The result comes back as a
+// chatCompletedMsg for Update to install.
+func sendChatMessage(m *model) tea.Cmd {
+	message := m.currentUserMessage
+	if message == "" {
+		log.Println("No user message to send.")
+		m.loading = false
+		return nil
+	}
+
+	agents, err := m.chainAgents()
+	if err != nil {
+		return func() tea.Msg { return errMsg(err) }
+	}
+
+	userMessage := map[string]string{
+		"role":      "user",
+		"content":   message,
+		"timestamp": messageTimestamp(),
+	}
+	var images []string
+	if len(m.pendingImages) > 0 {
+		var names []string
+		var err error
+		images, names, err = loadImages(m.pendingImages, m.attachmentTypes)
+		if err != nil {
+			return func() tea.Msg { return errMsg(fmt.Errorf("failed to attach images: %w", err)) }
 		}
+		userMessage["images"] = strings.Join(images, ",")
+		userMessage["image_files"] = strings.Join(names, ", ")
+		m.pendingImages = nil
+	}
+	// attached files reach the agents with this message but aren't kept in
+	// the conversation, so later turns don't resend them
+	chainInput, fileNames, err := withAttachedFiles(message, m.pendingFiles)
+	if err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to attach files: %w", err)) }
+	}
+	if len(fileNames) > 0 {
+		userMessage["attached_files"] = strings.Join(fileNames, ", ")
+		userMessage["attached_paths"] = strings.Join(m.pendingFiles, "\n")
+		m.pendingFiles = nil
+	}
+	return m.startChain(userMessage, chainInput, images, agents)
+}
+
+// chainAgents returns the enabled agents a message would be sent through, or
+// an error when there are none or one of them has no model to run on.
+func (m *model) chainAgents() ([]Agent, error) {
+	agents := m.enabledAgents()
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("no enabled agents configured")
+	}
+	for _, agent := range agents {
+		if strings.TrimSpace(agent.ModelVersion) == "" {
+			return nil, fmt.Errorf("agent '%s' has no model configured; choose one in the Agent view", agent.Role)
+		}
+	}
+	return agents, nil
+}
+
+// startChain appends userMessage to the conversation and runs chainInput,
+// the message with any attached files, through agents in the background.
+func (m *model) startChain(userMessage map[string]string, chainInput string, images []string, agents []Agent) tea.Cmd {
+	m.conversationHistory = append(m.conversationHistory, userMessage)
+	m.updateViewport()
+
+	req := chatRequest{
+		message:       chainInput,
+		history:       make([]map[string]string, len(m.conversationHistory)),
+		images:        images,
+		agents:        agents,
+		tools:         m.chainTools(),
+		visionModels:  append([]string(nil), m.config.VisionModels...),
+		recordUsage:   m.recordToolUsage,
+		projectPrompt: m.projectSystemPrompt(),
+		toolOutputMax: m.toolOutputLimit(),
+	}
+	copy(req.history, m.conversationHistory)
+
+	// save the user message now so it survives a crash during generation
+	return tea.Batch(m.scheduleAutoSave(), func() tea.Msg {
+		return req.run()
+	})
+}
+
+// run sends the message through the agent chain. Each sequential agent gets
+// the previous agent's response as input unless it opts out of UseChainInput,
+// and consecutive parallel agents all get the original message. Replies are
+// appended to the request's history as they arrive, so agents using the
+// conversation see the earlier ones.
+//
+// An agent with ContinueOnError that fails gets an error note as its reply
+// and the next agent gets the input it would have had; any other failure
+// stops the chain, keeping the replies so far.
+func (r chatRequest) run() chatCompletedMsg {
+	currentInput := r.message
+	var lastResponse string
+	var skipped []string
 
-		var lastResponse string
-		currentInput := m.currentUserMessage
+	for i := 0; i < len(r.agents); {
+		agent := r.agents[i]
 
-		for _, agent := range m.agents {
-			response, err := processAgentChain(currentInput, m, agent)
+		if !agent.Parallel {
+			input := currentInput
+			if !agent.UseChainInput {
+				input = r.message
+			}
+			response, stats, err := processAgentChain(input, r, agent)
 			if err != nil {
-				return errMsg(fmt.Errorf("error processing agent '%s': %w", agent.Role, err))
+				err = fmt.Errorf("error processing agent '%s': %w", agent.Role, err)
+				if !agent.ContinueOnError {
+					return chatCompletedMsg{history: r.history, skipped: skipped, err: err}
+				}
+				r.history = append(r.history, agentErrorMessage(agent, err))
+				skipped = append(skipped, agent.Role)
+				i++
+				continue
 			}
+			r.history = append(r.history, agentReplyMessage(agent, response, stats))
 			lastResponse = response
 			currentInput = response
+			i++
+			continue
+		}
 
-			m.conversationHistory = append(m.conversationHistory, map[string]string{
-				"role":    "assistant",
-				"content": response,
-			})
+		// consecutive parallel agents run together on the original message
+		end := i
+		for end < len(r.agents) && r.agents[end].Parallel {
+			end++
 		}
 
-		m.assistantResponses = append(m.assistantResponses, lastResponse)
-		m.userMessages = append(m.userMessages, m.currentUserMessage)
-		m.currentUserMessage = ""
-		m.loading = false
-		m.viewMode = ChatView
-		m.textarea.Blur()
+		responses, stats, errs := runParallelAgents(r.message, r, r.agents[i:end])
+		var succeeded []string
+		var abort error
+		for j, response := range responses {
+			agent := r.agents[i+j]
+			if errs[j] != nil {
+				err := fmt.Errorf("error processing agent '%s': %w", agent.Role, errs[j])
+				if !agent.ContinueOnError && abort == nil {
+					abort = err
+				}
+				r.history = append(r.history, agentErrorMessage(agent, err))
+				skipped = append(skipped, agent.Role)
+				continue
+			}
+			r.history = append(r.history, agentReplyMessage(agent, response, stats[j]))
+			succeeded = append(succeeded, response)
+		}
+		if abort != nil {
+			return chatCompletedMsg{history: r.history, skipped: skipped, err: abort}
+		}
+		if len(succeeded) > 0 {
+			lastResponse = strings.Join(succeeded, "\n\n")
+			currentInput = lastResponse
+		}
+		i = end
+	}
+
+	return chatCompletedMsg{history: r.history, lastResponse: lastResponse, skipped: skipped}
+}
+
+// agentReplyMessage records an agent's reply in the conversation, along with
+// its token and timing stats when Ollama reported them.
+func agentReplyMessage(agent Agent, response string, stats responseStats) map[string]string {
+	message := map[string]string{
+		"role":      "assistant",
+		"content":   response,
+		"agent":     agent.Role,
+		"timestamp": messageTimestamp(),
+	}
+	if summary := stats.String(); summary != "" {
+		message["stats"] = summary
+	}
+	return message
+}
+
+// agentErrorMessage records a failed agent in the conversation. The "error"
+// key keeps it out of the history later agents are sent.
+func agentErrorMessage(agent Agent, err error) map[string]string {
+	return map[string]string{
+		"role":      "assistant",
+		"content":   fmt.Sprintf("[agent failed: %v]", err),
+		"agent":     agent.Role,
+		"error":     "true",
+		"timestamp": messageTimestamp(),
+	}
+}
+
+// messageTimestamp is the "timestamp" value recorded on each new message.
+// Chats saved before timestamps were added simply lack the key.
+func messageTimestamp() string {
+	return time.Now().Format(time.RFC3339)
+}
+
+// messageTimeLabel renders a message's timestamp as a faint, right-aligned
+// line, showing only the time for messages sent today. Messages without a
+// valid timestamp get no label.
+func messageTimeLabel(msg map[string]string, width int) string {
+	sent, err := time.Parse(time.RFC3339, msg["timestamp"])
+	if err != nil {
+		return ""
+	}
+	sent = sent.Local()
+
+	label := sent.Format("2006-01-02 15:04")
+	if now := time.Now(); sent.YearDay() == now.YearDay() && sent.Year() == now.Year() {
+		label = sent.Format("15:04")
+	}
+	return lipgloss.NewStyle().
+		Width(width).
+		Align(lipgloss.Right).
+		Foreground(activeTheme.Muted).
+		Render(label) + "\n"
+}
+
+// applyChatCompleted installs the conversation produced by an agent chain,
+// including the replies from before a failure, and saves the chat.
+func (m *model) applyChatCompleted(msg chatCompletedMsg) tea.Cmd {
+	m.conversationHistory = msg.history
+	m.loading = false
+
+	if msg.err != nil {
+		m.errorMessage = msg.err.Error()
+		m.missingModel, _ = missingModelName(msg.err)
+		m.updateViewport()
+		// keep the replies that arrived before the failure
+		return m.scheduleAutoSave()
+	}
+
+	m.assistantResponses = append(m.assistantResponses, msg.lastResponse)
+	m.userMessages = append(m.userMessages, m.currentUserMessage)
+	m.currentUserMessage = ""
+	m.updateViewport()
+
+	if err := m.saveCurrentChat(); err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to save chat: %w", err)) }
+	}
+	if len(msg.skipped) > 0 {
+		return m.showToast(fmt.Sprintf("Skipped after failing: %s", strings.Join(msg.skipped, ", ")))
+	}
+	return nil
+}
+
+type agentResult struct {
+	index    int
+	response string
+	stats    responseStats
+	err      error
+}
+
+// runParallelAgents fans the input out to every agent at once and returns the
+// responses, stats and errors in agent order, regardless of which finished
+// first.
+func runParallelAgents(input string, req chatRequest, agents []Agent) ([]string, []responseStats, []error) {
+	results := make(chan agentResult, len(agents))
+	var wg sync.WaitGroup
+
+	for i, agent := range agents {
+		wg.Add(1)
+		go func(index int, agent Agent) {
+			defer wg.Done()
+			response, stats, err := processAgentChain(input, req, agent)
+			results <- agentResult{index: index, response: response, stats: stats, err: err}
+		}(i, agent)
+	}
+
+	wg.Wait()
+	close(results)
+
+	responses := make([]string, len(agents))
+	stats := make([]responseStats, len(agents))
+	errs := make([]error, len(agents))
+	for result := range results {
+		responses[result.index] = result.response
+		stats[result.index] = result.stats
+		errs[result.index] = result.err
+	}
+
+	return responses, stats, errs
+}
+
+// toggleRawOutput switches the conversation between rendered markdown and
+// the raw text, keeping the viewport at the same relative position.
+func (m *model) toggleRawOutput() {
+	percent := m.viewport.ScrollPercent()
+	atBottom := m.viewport.AtBottom()
+
+	m.rawOutput = !m.rawOutput
+	m.updateViewport()
+
+	if !atBottom {
+		maxOffset := max(0, m.viewport.TotalLineCount()-m.viewport.Height)
+		m.viewport.SetYOffset(int(percent * float64(maxOffset)))
+	}
+}
+
+// blockedWhileGenerating reports whether key would change the conversation,
+// the current chat or the agents while the agent chain is still running and
+// appending to them.
+func blockedWhileGenerating(mode viewMode, key string) bool {
+	switch mode {
+	case ChatView:
+		switch key {
+		case "R", "C", "v", "l", "s", "c", "ctrl+l":
+			return true
+		}
+	case AgentView:
+		switch key {
+		case "enter", "a", "e", "d", "c", "u", "y", "ctrl+u", " ", "i", "r":
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) busyToast() tea.Cmd {
+	return m.showToast("Still generating a response, wait for it to finish.")
+}
+
+// regenerateLastResponse drops every assistant message produced by the last
+// turn of the agent chain and re-sends the user message that started it.
+func (m *model) regenerateLastResponse() tea.Cmd {
+	for i := len(m.conversationHistory) - 1; i >= 0; i-- {
+		if m.conversationHistory[i]["role"] == "user" {
+			return m.regenerateFrom(i)
+		}
+	}
+
+	return func() tea.Msg {
+		return notifyMsg("No previous message to regenerate.")
+	}
+}
+
+// continuePrompt asks an agent to pick up a response that was cut off.
+const continuePrompt = "Continue your previous response exactly where it stopped. Don't repeat anything you already wrote."
+
+// continueLastResponse asks the agent that wrote the last response to carry
+// on from where it stopped, for replies cut off by the output limit. The new
+// text is appended to that response rather than added as a separate turn.
+// Only the agent that wrote the response is run, not the rest of the chain.
+func (m *model) continueLastResponse() tea.Cmd {
+	if m.loading {
+		return m.busyToast()
+	}
+
+	index := len(m.conversationHistory) - 1
+	if index < 0 || m.conversationHistory[index]["role"] != "assistant" || m.conversationHistory[index]["error"] != "" {
+		return m.showToast("The last message isn't a response that can be continued.")
+	}
+
+	role := m.conversationHistory[index]["agent"]
+	var agent Agent
+	found := false
+	for _, candidate := range m.agents {
+		if strings.EqualFold(candidate.Role, role) {
+			agent, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return m.showToast(fmt.Sprintf("Agent '%s' no longer exists, so its response can't be continued.", role))
+	}
+	// the agent has to see its own unfinished reply to continue it
+	agent.UseConversation = true
+
+	req := chatRequest{
+		message:       continuePrompt,
+		history:       make([]map[string]string, len(m.conversationHistory)),
+		tools:         m.chainTools(),
+		visionModels:  append([]string(nil), m.config.VisionModels...),
+		recordUsage:   m.recordToolUsage,
+		projectPrompt: m.projectSystemPrompt(),
+		toolOutputMax: m.toolOutputLimit(),
+	}
+	copy(req.history, m.conversationHistory)
+
+	m.loading = true
+	m.refreshViewportContent()
+	return func() tea.Msg {
+		response, stats, err := processAgentChain(continuePrompt, req, agent)
+		if err != nil {
+			return responseContinuedMsg{index: index, err: fmt.Errorf("error continuing agent '%s': %w", agent.Role, err)}
+		}
+		text := strings.TrimPrefix(response, responseHeader(agent.Role))
+		return responseContinuedMsg{index: index, text: text, stats: stats}
+	}
+}
+
+// applyResponseContinued appends continued text to the response it extends
+// and saves the chat.
+func (m *model) applyResponseContinued(msg responseContinuedMsg) tea.Cmd {
+	m.loading = false
+	if msg.err != nil {
+		m.errorMessage = msg.err.Error()
+		m.missingModel, _ = missingModelName(msg.err)
+		m.updateViewport()
+		return nil
+	}
+	// the conversation may have been cleared or switched meanwhile
+	if msg.index >= len(m.conversationHistory) || m.conversationHistory[msg.index]["role"] != "assistant" {
 		m.updateViewport()
+		return nil
+	}
+
+	message := m.conversationHistory[msg.index]
+	message["content"] += msg.text
+	if summary := msg.stats.String(); summary != "" {
+		message["stats"] = summary
+	}
+	m.updateViewport()
+
+	if err := m.saveCurrentChat(); err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to save chat: %w", err)) }
+	}
+	return nil
+}
+
+// regenerateFrom discards the user message at index and everything after it,
+// then re-sends that message, with its images and attached files, through the
+// agent chain. Nothing is discarded when the message can't be sent.
+func (m *model) regenerateFrom(index int) tea.Cmd {
+	if index < 0 || index >= len(m.conversationHistory) {
+		return nil
+	}
+	if m.loading {
+		return m.busyToast()
+	}
+
+	agents, err := m.chainAgents()
+	if err != nil {
+		return func() tea.Msg { return errMsg(err) }
+	}
+
+	original := m.conversationHistory[index]
+	userMessage := make(map[string]string, len(original))
+	for key, value := range original {
+		userMessage[key] = value
+	}
+	userMessage["timestamp"] = messageTimestamp()
+
+	// file contents aren't kept in the conversation, so they are read again;
+	// messages saved before their paths were recorded can't resend them
+	var paths []string
+	if original["attached_paths"] != "" {
+		paths = strings.Split(original["attached_paths"], "\n")
+	} else {
+		delete(userMessage, "attached_files")
+	}
+	chainInput, _, err := withAttachedFiles(original["content"], paths)
+	if err != nil {
+		return func() tea.Msg { return errMsg(fmt.Errorf("failed to attach files: %w", err)) }
+	}
+	var images []string
+	if original["images"] != "" {
+		images = strings.Split(original["images"], ",")
+	}
+
+	m.currentUserMessage = original["content"]
+	m.conversationHistory = m.conversationHistory[:index]
+	m.loading = true
+	return m.startChain(userMessage, chainInput, images, agents)
+}
 
-		if err := m.saveCurrentChat(); err != nil {
+// clearConversation empties the current chat, keeping the chat itself. Named
+// chats are saved empty; temporary chats are only reset in memory.
+func (m *model) clearConversation() tea.Cmd {
+	m.conversationHistory = []map[string]string{}
+	m.userMessages = nil
+	m.assistantResponses = nil
+	m.pendingImages = nil
+	m.pendingFiles = nil
+	m.selectedImage = ""
+	m.clearSearch()
+	m.textarea.Focus()
+	m.updateViewport()
+
+	if err := m.saveCurrentChat(); err != nil {
+		return func() tea.Msg {
 			return errMsg(fmt.Errorf("failed to save chat: %w", err))
 		}
+	}
+	return m.showToast("Conversation cleared.")
+}
 
-		return responseMsg("Conversation processed successfully.")
+func (m *model) deleteSelectedMessage() tea.Cmd {
+	if m.selectedMessage < 0 || m.selectedMessage >= len(m.conversationHistory) {
+		return nil
+	}
+
+	m.conversationHistory = append(m.conversationHistory[:m.selectedMessage], m.conversationHistory[m.selectedMessage+1:]...)
+	if m.selectedMessage >= len(m.conversationHistory) {
+		m.selectedMessage = len(m.conversationHistory) - 1
+	}
+	if len(m.conversationHistory) == 0 {
+		m.viewMode = ChatView
+	}
+	m.updateViewport()
+
+	if err := m.saveCurrentChat(); err != nil {
+		return func() tea.Msg {
+			return errMsg(fmt.Errorf("failed to save chat: %w", err))
+		}
 	}
+	return nil
+}
+
+// applyMessageEdit writes the textarea content back into the message being
+// edited. Editing a user message that has replies offers to regenerate them.
+func (m *model) applyMessageEdit() tea.Cmd {
+	index := m.editingMessage
+	m.editingMessage = -1
+
+	m.conversationHistory[index]["content"] = m.textarea.Value()
+	m.textarea.Reset()
+	m.textarea.Blur()
+	m.viewMode = ChatView
+	m.updateViewport()
+
+	if err := m.saveCurrentChat(); err != nil {
+		return func() tea.Msg {
+			return errMsg(fmt.Errorf("failed to save chat: %w", err))
+		}
+	}
+
+	if m.conversationHistory[index]["role"] == "user" && index < len(m.conversationHistory)-1 {
+		m.editedMessage = index
+		m.confirmDeleteType = "regenerate"
+		m.confirmForm = createConfirmForm("Regenerate all responses after the edited message?", &m.confirmResult)
+		m.viewMode = ConfirmDelete
+	}
+
+	return nil
 }